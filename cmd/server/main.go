@@ -1,18 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"studyforge/internal/api/handlers"
 	"studyforge/internal/config"
 	"studyforge/internal/repository"
 	"studyforge/internal/services"
 	"studyforge/pkg/ai"
+	"studyforge/pkg/prompts"
 	"studyforge/pkg/utils"
 )
 
@@ -39,15 +43,66 @@ func main() {
 	sessionRepo := repository.NewSessionRepository(db.DB)
 	docRepo := repository.NewDocumentRepository(db.DB)
 	contentRepo := repository.NewContentRepository(db.DB)
+	flashcardRepo := repository.NewFlashcardRepository(db.DB)
+	glossaryRepo := repository.NewGlossaryRepository(db.DB)
+	passageRepo := repository.NewPassageRepository(db.DB)
+	promptTemplateRepo := repository.NewPromptTemplateRepository(db.DB)
+	jobRepo := repository.NewJobRepository(db.DB)
 
 	// Initialize services
 	pdfService := services.NewPDFService(contentRepo)
-	aiClient := ai.NewHuggingFaceClient(cfg.HuggingFaceKey, cfg.HuggingFaceURL)
-	studyService := services.NewStudyService(aiClient, pdfService, contentRepo, docRepo)
+	chainConfig, err := cfg.ChainConfig()
+	if err != nil {
+		log.Fatalf("Invalid AI provider chains: %v", err)
+	}
+	chains, err := ai.NewChains(chainConfig, cfg.ProviderConfig())
+	if err != nil {
+		log.Fatalf("Failed to initialize AI provider: %v", err)
+	}
+	var aiProvider ai.Provider = chains.Primary()
+	log.Printf("AI provider: %s (model %s), fallbacks: %s", aiProvider.ModelInfo().Provider, aiProvider.ModelInfo().Model, strings.Join(chainConfig.Default[1:], ", "))
+	for material, chain := range chainConfig.Materials {
+		log.Printf("AI provider chain for %s: %s", material, strings.Join(chain, " -> "))
+	}
+
+	// Verify the configured model is available before accepting requests
+	var aiErr error
+	if checker, ok := aiProvider.(ai.ModelChecker); ok {
+		checkCtx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		aiErr = checker.CheckModel(checkCtx)
+		cancel()
+		if aiErr != nil {
+			if cfg.AIFailFast {
+				log.Fatalf("AI model check failed: %v", aiErr)
+			}
+			log.Printf("Warning: AI model check failed, running degraded: %v", aiErr)
+		}
+	}
+
+	// Load the prompt library, from disk when PROMPTS_DIR is set
+	promptLibrary, err := prompts.Open(cfg.PromptsDir)
+	if err != nil {
+		log.Fatalf("Failed to load prompt library: %v", err)
+	}
+	log.Printf("Prompt library version %s (subjects: %s)", promptLibrary.Version(), strings.Join(promptLibrary.Subjects(), ", "))
+
+	studyService := services.NewStudyService(cfg, chains, promptLibrary, pdfService, contentRepo, docRepo, flashcardRepo, glossaryRepo, passageRepo, promptTemplateRepo)
 
 	// Initialize handlers
 	pdfHandler := handlers.NewPDFHandler(cfg, docRepo, pdfService)
-	studyHandler := handlers.NewStudyHandler(studyService)
+	jobQueue := services.NewJobQueue(jobRepo, cfg.JobWorkers, cfg.JobMaxAttempts)
+	studyHandler := handlers.NewStudyHandler(studyService, jobQueue)
+	jobHandler := handlers.NewJobHandler(jobQueue)
+	flashcardHandler := handlers.NewFlashcardHandler(studyService)
+	glossaryHandler := handlers.NewGlossaryHandler(studyService)
+	askHandler := handlers.NewAskHandler(studyService)
+	customPromptHandler := handlers.NewCustomPromptHandler(studyService)
+	healthHandler := handlers.NewHealthHandler(aiProvider.ModelInfo(), aiErr, chains)
+
+	// Start job workers; jobs interrupted by the last shutdown resume here
+	if err := jobQueue.Start(context.Background(), studyService.RunJob); err != nil {
+		log.Fatalf("Failed to start job queue: %v", err)
+	}
 
 	// Initialize session manager
 	sessionManager := utils.NewSessionManager(sessionRepo)
@@ -56,11 +111,20 @@ func main() {
 	mux := http.NewServeMux()
 
 	// API routes
-	mux.HandleFunc("/api/health", handlers.HandleHealth)
+	mux.HandleFunc("/api/health", healthHandler.HandleHealth)
 	mux.HandleFunc("/api/documents/upload", pdfHandler.HandleUpload)
 	mux.HandleFunc("/api/documents", pdfHandler.HandleGetDocument)
 	mux.HandleFunc("/api/study/generate", studyHandler.HandleGenerate)
+	mux.HandleFunc("/api/study/generate/stream", studyHandler.HandleGenerateStream)
+	mux.HandleFunc("/api/study/jobs", jobHandler.HandleJobs)
 	mux.HandleFunc("/api/study/content", studyHandler.HandleGetContent)
+	mux.HandleFunc("/api/study/concept-map/export", studyHandler.HandleExportConceptMap)
+	mux.HandleFunc("/api/study/flashcards", flashcardHandler.HandleFlashcards)
+	mux.HandleFunc("/api/study/flashcards/review", flashcardHandler.HandleReview)
+	mux.HandleFunc("/api/study/glossary", glossaryHandler.HandleGlossary)
+	mux.HandleFunc("/api/study/ask", askHandler.HandleAsk)
+	mux.HandleFunc("/api/study/custom-prompt", customPromptHandler.HandleCustomPrompt)
+	mux.HandleFunc("/api/study/prompt-templates", customPromptHandler.HandleTemplates)
 
 	// Serve static files
 	fs := http.FileServer(http.Dir("./web"))
@@ -92,6 +156,9 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+
+	// Stop job workers; running jobs stay marked running and are requeued on the next start
+	jobQueue.Stop()
 }
 
 // corsMiddleware adds CORS headers
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"studyforge/internal/services"
+	"studyforge/pkg/utils"
+)
+
+// AskHandler handles document question answering requests
+type AskHandler struct {
+	studyService *services.StudyService
+}
+
+// NewAskHandler creates a new ask handler
+func NewAskHandler(studyService *services.StudyService) *AskHandler {
+	return &AskHandler{
+		studyService: studyService,
+	}
+}
+
+// AskRequest represents a question about a document
+type AskRequest struct {
+	DocumentID int    `json:"document_id"`
+	Question   string `json:"question"`
+	PageStart  int    `json:"page_start"` // optional page range to search
+	PageEnd    int    `json:"page_end"`
+	TopK       int    `json:"top_k"` // passages to retrieve, default 5
+}
+
+// HandleAsk answers a question about a document from retrieved passages
+func (h *AskHandler) HandleAsk(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	// Get session
+	session, err := utils.GetSessionFromContext(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, "NO_SESSION", "No session found")
+		return
+	}
+
+	var req AskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return
+	}
+
+	// Validate request
+	if req.DocumentID <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_DOCUMENT_ID", "Invalid document ID")
+		return
+	}
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" || len(req.Question) > services.MaxQuestionLength {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_QUESTION",
+			fmt.Sprintf("Question must be between 1 and %d characters", services.MaxQuestionLength))
+		return
+	}
+	if req.PageStart < 0 || req.PageEnd < 0 || (req.PageEnd > 0 && req.PageStart > req.PageEnd) {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PAGE_RANGE", "Invalid page range")
+		return
+	}
+	if req.TopK < 0 || req.TopK > services.MaxAskPassages {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_TOP_K",
+			fmt.Sprintf("top_k must be between 1 and %d", services.MaxAskPassages))
+		return
+	}
+
+	log.Printf("Answering question about document %d", req.DocumentID)
+
+	result, err := h.studyService.Ask(r.Context(), &services.AskRequest{
+		SessionID:  session.ID,
+		DocumentID: req.DocumentID,
+		Question:   req.Question,
+		PageStart:  req.PageStart,
+		PageEnd:    req.PageEnd,
+		TopK:       req.TopK,
+	})
+	if errors.Is(err, services.ErrPageRangeRequired) {
+		utils.WriteError(w, http.StatusBadRequest, "PAGE_RANGE_REQUIRED", err.Error())
+		return
+	}
+	if err != nil {
+		status, errInfo := generateError(err)
+		utils.WriteError(w, status, errInfo.Code, errInfo.Message)
+		return
+	}
+
+	log.Printf("Question answered (document %d: %d passages, %s retrieval)", req.DocumentID, len(result.Passages), result.Retrieval)
+
+	utils.WriteJSON(w, http.StatusOK, result)
+}
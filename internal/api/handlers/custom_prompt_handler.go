@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"studyforge/internal/services"
+	"studyforge/pkg/utils"
+)
+
+// CustomPromptHandler handles custom prompts and saved prompt templates
+type CustomPromptHandler struct {
+	studyService *services.StudyService
+}
+
+// NewCustomPromptHandler creates a new custom prompt handler
+func NewCustomPromptHandler(studyService *services.StudyService) *CustomPromptHandler {
+	return &CustomPromptHandler{
+		studyService: studyService,
+	}
+}
+
+// CustomPromptRequest represents a custom prompt applied to a page range.
+// Either Prompt or TemplateID is required.
+type CustomPromptRequest struct {
+	DocumentID    int    `json:"document_id"`
+	PageStart     int    `json:"page_start"`
+	PageEnd       int    `json:"page_end"`
+	Prompt        string `json:"prompt"`
+	PromptType    string `json:"prompt_type"`    // 'question', 'notes', 'quiz', 'flashcards', 'explanation', 'timeline', 'custom'
+	TemplateID    int    `json:"template_id"`    // saved template to use instead of prompt
+	AcademicLevel string `json:"academic_level"` // 'high_school', 'undergraduate', 'graduate'
+}
+
+// PromptTemplateRequest represents a prompt template to create or edit
+type PromptTemplateRequest struct {
+	Name     string `json:"name"`
+	Template string `json:"template"` // may use {{text}}, {{level}} and {{pages}}
+}
+
+// HandleCustomPrompt applies a free-form prompt or saved template to a page
+// range
+func (h *CustomPromptHandler) HandleCustomPrompt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	// Get session
+	session, err := utils.GetSessionFromContext(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, "NO_SESSION", "No session found")
+		return
+	}
+
+	var req CustomPromptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return
+	}
+
+	// Validate request
+	if req.DocumentID <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_DOCUMENT_ID", "Invalid document ID")
+		return
+	}
+	if req.PageStart < 1 || req.PageEnd < req.PageStart {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PAGE_RANGE", "Invalid page range")
+		return
+	}
+	if req.TemplateID < 0 {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid template ID")
+		return
+	}
+	if req.TemplateID == 0 {
+		if err := services.ValidateCustomPrompt(req.Prompt); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_PROMPT", err.Error())
+			return
+		}
+	}
+	if req.PromptType != "" && !slices.Contains(services.CustomPromptTypes, req.PromptType) {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PROMPT_TYPE", "Unsupported prompt type: "+req.PromptType)
+		return
+	}
+
+	// Default academic level if not provided
+	if req.AcademicLevel == "" {
+		req.AcademicLevel = "undergraduate"
+	}
+
+	log.Printf("Running custom prompt for document %d, pages %d-%d", req.DocumentID, req.PageStart, req.PageEnd)
+
+	result, err := h.studyService.RunCustomPrompt(r.Context(), &services.CustomPromptRequest{
+		SessionID:     session.ID,
+		DocumentID:    req.DocumentID,
+		PageStart:     req.PageStart,
+		PageEnd:       req.PageEnd,
+		AcademicLevel: req.AcademicLevel,
+		Prompt:        req.Prompt,
+		PromptType:    req.PromptType,
+		TemplateID:    req.TemplateID,
+	})
+	if err != nil {
+		status, errInfo := generateError(err)
+		utils.WriteError(w, status, errInfo.Code, errInfo.Message)
+		return
+	}
+
+	log.Printf("Custom prompt completed successfully (ID: %d)", result.ContentID)
+
+	utils.WriteJSON(w, http.StatusOK, result)
+}
+
+// HandleTemplates lists a session's prompt templates (GET), creates one
+// (POST), edits one (PUT ?id=N) or deletes one (DELETE ?id=N)
+func (h *CustomPromptHandler) HandleTemplates(w http.ResponseWriter, r *http.Request) {
+	// Get session
+	session, err := utils.GetSessionFromContext(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, "NO_SESSION", "No session found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.listTemplates(w, r, session.ID)
+	case http.MethodPost:
+		h.createTemplate(w, r, session.ID)
+	case http.MethodPut:
+		h.updateTemplate(w, r, session.ID)
+	case http.MethodDelete:
+		h.deleteTemplate(w, r, session.ID)
+	default:
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+	}
+}
+
+// listTemplates returns the session's templates
+func (h *CustomPromptHandler) listTemplates(w http.ResponseWriter, r *http.Request, sessionID string) {
+	templates, err := h.studyService.ListPromptTemplates(r.Context(), sessionID)
+	if err != nil {
+		log.Printf("Failed to list prompt templates: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to load prompt templates")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"templates": templates,
+	})
+}
+
+// createTemplate saves a new template
+func (h *CustomPromptHandler) createTemplate(w http.ResponseWriter, r *http.Request, sessionID string) {
+	var req PromptTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return
+	}
+
+	template, err := h.studyService.CreatePromptTemplate(r.Context(), sessionID, req.Name, req.Template)
+	if err != nil {
+		if !writeTemplateError(w, err) {
+			log.Printf("Failed to create prompt template: %v", err)
+			utils.WriteError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to save prompt template")
+		}
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, template)
+}
+
+// updateTemplate renames or rewrites a template
+func (h *CustomPromptHandler) updateTemplate(w http.ResponseWriter, r *http.Request, sessionID string) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid template ID")
+		return
+	}
+
+	var req PromptTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return
+	}
+
+	template, err := h.studyService.UpdatePromptTemplate(r.Context(), sessionID, id, req.Name, req.Template)
+	if err != nil {
+		if !writeTemplateError(w, err) {
+			log.Printf("Failed to update prompt template: %v", err)
+			utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", "Prompt template not found")
+		}
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, template)
+}
+
+// deleteTemplate removes a template
+func (h *CustomPromptHandler) deleteTemplate(w http.ResponseWriter, r *http.Request, sessionID string) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid template ID")
+		return
+	}
+
+	if err := h.studyService.DeletePromptTemplate(r.Context(), sessionID, id); err != nil {
+		log.Printf("Failed to delete prompt template: %v", err)
+		utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", "Prompt template not found")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"id":      id,
+		"deleted": true,
+	})
+}
+
+// writeTemplateError writes the response for template validation and
+// duplicate name errors, reporting whether err was one of them
+func writeTemplateError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrInvalidPrompt):
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_TEMPLATE", err.Error())
+	case errors.Is(err, services.ErrDuplicateTemplate):
+		utils.WriteError(w, http.StatusConflict, "DUPLICATE_TEMPLATE", err.Error())
+	default:
+		return false
+	}
+	return true
+}
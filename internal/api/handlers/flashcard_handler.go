@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"studyforge/internal/services"
+	"studyforge/pkg/utils"
+)
+
+// FlashcardHandler handles requests for individual flashcards
+type FlashcardHandler struct {
+	studyService *services.StudyService
+}
+
+// NewFlashcardHandler creates a new flashcard handler
+func NewFlashcardHandler(studyService *services.StudyService) *FlashcardHandler {
+	return &FlashcardHandler{
+		studyService: studyService,
+	}
+}
+
+// UpdateFlashcardRequest represents an edit to a flashcard
+type UpdateFlashcardRequest struct {
+	Front string `json:"front"`
+	Back  string `json:"back"`
+	Kind  string `json:"kind"` // 'term', 'person', 'date', 'concept'
+}
+
+// ReviewFlashcardRequest records the result of reviewing a flashcard
+type ReviewFlashcardRequest struct {
+	ID      int  `json:"id"`
+	Correct bool `json:"correct"`
+}
+
+// HandleFlashcards lists a deck (GET ?content_id=N[&due=true]), edits a card
+// (PUT ?id=N) or deletes a card (DELETE ?id=N)
+func (h *FlashcardHandler) HandleFlashcards(w http.ResponseWriter, r *http.Request) {
+	// Get session
+	session, err := utils.GetSessionFromContext(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, "NO_SESSION", "No session found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.listFlashcards(w, r, session.ID)
+	case http.MethodPut:
+		h.updateFlashcard(w, r, session.ID)
+	case http.MethodDelete:
+		h.deleteFlashcard(w, r, session.ID)
+	default:
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+	}
+}
+
+// listFlashcards returns the cards of a deck
+func (h *FlashcardHandler) listFlashcards(w http.ResponseWriter, r *http.Request, sessionID string) {
+	contentID, err := strconv.Atoi(r.URL.Query().Get("content_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid content ID")
+		return
+	}
+	dueOnly := r.URL.Query().Get("due") == "true"
+
+	cards, err := h.studyService.ListFlashcards(r.Context(), sessionID, contentID, dueOnly)
+	if err != nil {
+		log.Printf("Failed to list flashcards: %v", err)
+		utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", "Flashcard deck not found")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"content_id": contentID,
+		"cards":      cards,
+	})
+}
+
+// updateFlashcard edits the front, back or kind of a card
+func (h *FlashcardHandler) updateFlashcard(w http.ResponseWriter, r *http.Request, sessionID string) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid flashcard ID")
+		return
+	}
+
+	var req UpdateFlashcardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return
+	}
+	if req.Kind != "" && !slices.Contains(services.FlashcardKinds, req.Kind) {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_KIND", "Unsupported flashcard kind: "+req.Kind)
+		return
+	}
+
+	card, err := h.studyService.UpdateFlashcard(r.Context(), sessionID, id, req.Front, req.Back, req.Kind)
+	if err != nil {
+		log.Printf("Failed to update flashcard: %v", err)
+		utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", "Flashcard not found")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, card)
+}
+
+// deleteFlashcard removes a card from its deck
+func (h *FlashcardHandler) deleteFlashcard(w http.ResponseWriter, r *http.Request, sessionID string) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid flashcard ID")
+		return
+	}
+
+	if err := h.studyService.DeleteFlashcard(r.Context(), sessionID, id); err != nil {
+		log.Printf("Failed to delete flashcard: %v", err)
+		utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", "Flashcard not found")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"id":      id,
+		"deleted": true,
+	})
+}
+
+// HandleReview records whether a card was answered correctly and returns
+// the card with its next due date
+func (h *FlashcardHandler) HandleReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	// Get session
+	session, err := utils.GetSessionFromContext(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, "NO_SESSION", "No session found")
+		return
+	}
+
+	var req ReviewFlashcardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return
+	}
+	if req.ID <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid flashcard ID")
+		return
+	}
+
+	card, err := h.studyService.ReviewFlashcard(r.Context(), session.ID, req.ID, req.Correct)
+	if err != nil {
+		log.Printf("Failed to review flashcard: %v", err)
+		utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", "Flashcard not found")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, card)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"studyforge/internal/services"
+	"studyforge/pkg/utils"
+)
+
+// GlossaryHandler handles document glossary requests
+type GlossaryHandler struct {
+	studyService *services.StudyService
+}
+
+// NewGlossaryHandler creates a new glossary handler
+func NewGlossaryHandler(studyService *services.StudyService) *GlossaryHandler {
+	return &GlossaryHandler{
+		studyService: studyService,
+	}
+}
+
+// BuildGlossaryRequest represents a glossary build request
+type BuildGlossaryRequest struct {
+	DocumentID int  `json:"document_id"`
+	ExtractAll bool `json:"extract_all"` // extract every page first, instead of only using pages already extracted
+}
+
+// HandleGlossary builds or updates a document's glossary (POST) or returns
+// the stored glossary (GET ?document_id=N)
+func (h *GlossaryHandler) HandleGlossary(w http.ResponseWriter, r *http.Request) {
+	// Get session
+	session, err := utils.GetSessionFromContext(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, "NO_SESSION", "No session found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.buildGlossary(w, r, session.ID)
+	case http.MethodGet:
+		h.getGlossary(w, r, session.ID)
+	default:
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+	}
+}
+
+// buildGlossary scans pages not yet in the glossary and returns the result
+func (h *GlossaryHandler) buildGlossary(w http.ResponseWriter, r *http.Request, sessionID string) {
+	var req BuildGlossaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return
+	}
+	if req.DocumentID <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_DOCUMENT_ID", "Invalid document ID")
+		return
+	}
+
+	log.Printf("Building glossary for document %d (extract all: %t)", req.DocumentID, req.ExtractAll)
+
+	result, err := h.studyService.BuildGlossary(r.Context(), sessionID, req.DocumentID, req.ExtractAll)
+	if err != nil {
+		status, errInfo := generateError(err)
+		utils.WriteError(w, status, errInfo.Code, errInfo.Message)
+		return
+	}
+
+	log.Printf("Glossary updated (document %d: %d terms, %d new, %d pages scanned)",
+		result.DocumentID, len(result.Terms), result.NewTerms, result.PagesProcessed)
+
+	utils.WriteJSON(w, http.StatusOK, result)
+}
+
+// getGlossary returns the stored glossary without scanning new pages
+func (h *GlossaryHandler) getGlossary(w http.ResponseWriter, r *http.Request, sessionID string) {
+	documentID, err := strconv.Atoi(r.URL.Query().Get("document_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid document ID")
+		return
+	}
+
+	terms, err := h.studyService.GetGlossary(r.Context(), sessionID, documentID)
+	if err != nil {
+		log.Printf("Failed to get glossary: %v", err)
+		utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", "Document not found")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"document_id": documentID,
+		"terms":       terms,
+	})
+}
@@ -4,18 +4,50 @@ import (
 	"net/http"
 	"time"
 
+	"studyforge/pkg/ai"
 	"studyforge/pkg/utils"
 )
 
 var startTime = time.Now()
 
+// HealthHandler reports server and AI provider health
+type HealthHandler struct {
+	aiInfo  ai.ModelInfo
+	aiError error
+	chains  *ai.Chains
+}
+
+// NewHealthHandler creates a new health handler. aiError is the result of the
+// startup model check; a non-nil value marks the server as degraded.
+func NewHealthHandler(aiInfo ai.ModelInfo, aiError error, chains *ai.Chains) *HealthHandler {
+	return &HealthHandler{
+		aiInfo:  aiInfo,
+		aiError: aiError,
+		chains:  chains,
+	}
+}
+
 // HandleHealth returns health status
-func HandleHealth(w http.ResponseWriter, r *http.Request) {
+func (h *HealthHandler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	uptime := int(time.Since(startTime).Seconds())
 
+	status := "healthy"
+	aiStatus := map[string]interface{}{
+		"provider": h.aiInfo.Provider,
+		"model":    h.aiInfo.Model,
+		"status":   "available",
+		"circuits": h.chains.States(),
+	}
+	if h.aiError != nil {
+		status = "degraded"
+		aiStatus["status"] = "unavailable"
+		aiStatus["error"] = h.aiError.Error()
+	}
+
 	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
-		"status":  "healthy",
+		"status":  status,
 		"version": "1.0.0-mvp",
 		"uptime":  uptime,
+		"ai":      aiStatus,
 	})
 }
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"studyforge/internal/services"
+	"studyforge/pkg/utils"
+)
+
+// JobHandler handles requests for background generation jobs
+type JobHandler struct {
+	jobs *services.JobQueue
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(jobs *services.JobQueue) *JobHandler {
+	return &JobHandler{
+		jobs: jobs,
+	}
+}
+
+// HandleJobs returns a job (GET ?id=N), lists the session's recent jobs
+// (GET) or cancels a job (DELETE ?id=N)
+func (h *JobHandler) HandleJobs(w http.ResponseWriter, r *http.Request) {
+	// Get session
+	session, err := utils.GetSessionFromContext(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, "NO_SESSION", "No session found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if r.URL.Query().Get("id") == "" {
+			h.listJobs(w, r, session.ID)
+		} else {
+			h.getJob(w, r, session.ID)
+		}
+	case http.MethodDelete:
+		h.cancelJob(w, r, session.ID)
+	default:
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+	}
+}
+
+// getJob returns a job's status, progress and, once finished, its result
+func (h *JobHandler) getJob(w http.ResponseWriter, r *http.Request, sessionID string) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid job ID")
+		return
+	}
+
+	job, err := h.jobs.GetJob(r.Context(), id, sessionID)
+	if err != nil {
+		log.Printf("Failed to get job: %v", err)
+		utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", "Job not found")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, job)
+}
+
+// listJobs returns the session's most recent jobs
+func (h *JobHandler) listJobs(w http.ResponseWriter, r *http.Request, sessionID string) {
+	jobs, err := h.jobs.ListJobs(r.Context(), sessionID)
+	if err != nil {
+		log.Printf("Failed to list jobs: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to list jobs")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"jobs": jobs,
+	})
+}
+
+// cancelJob cancels a queued or running job
+func (h *JobHandler) cancelJob(w http.ResponseWriter, r *http.Request, sessionID string) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid job ID")
+		return
+	}
+
+	job, cancelled, err := h.jobs.CancelJob(r.Context(), id, sessionID)
+	if err != nil {
+		log.Printf("Failed to cancel job: %v", err)
+		utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", "Job not found")
+		return
+	}
+	if !cancelled {
+		utils.WriteError(w, http.StatusConflict, "JOB_FINISHED", "Job already "+job.Status)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, job)
+}
+
+// enqueueJob stores a validated generation request as a background job
+// and responds with its ID
+func (h *StudyHandler) enqueueJob(w http.ResponseWriter, r *http.Request, sessionID string, req *GenerateRequest) {
+	// A job cannot fix a bad document or page range by retrying, so those
+	// are rejected now rather than when the job runs
+	if err := h.studyService.CheckPages(r.Context(), sessionID, req.DocumentID, req.PageStart, req.PageEnd); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	request, err := json.Marshal(req.GenerateRequest)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to encode request")
+		return
+	}
+
+	materialType := req.MaterialType
+	if materialType == "" {
+		materialType = "summary"
+	}
+	job, err := h.jobs.Enqueue(r.Context(), sessionID, materialType, request)
+	if err != nil {
+		log.Printf("Failed to enqueue job: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to queue job")
+		return
+	}
+
+	log.Printf("Queued job %d: %s for document %d, pages %d-%d", job.ID, materialType, req.DocumentID, req.PageStart, req.PageEnd)
+
+	utils.WriteJSON(w, http.StatusAccepted, map[string]interface{}{
+		"job_id":        job.ID,
+		"status":        job.Status,
+		"material_type": materialType,
+	})
+}
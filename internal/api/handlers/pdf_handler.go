@@ -128,7 +128,7 @@ func (h *PDFHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
 		IsDeleted:        false,
 	}
 
-	if err := h.docRepo.Create(doc); err != nil {
+	if err := h.docRepo.Create(r.Context(), doc); err != nil {
 		log.Printf("Failed to save document record: %v", err)
 		os.Remove(filePath) // Clean up
 		utils.WriteError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to save document")
@@ -169,7 +169,7 @@ func (h *PDFHandler) HandleGetDocument(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get document
-	doc, err := h.docRepo.GetByID(docID)
+	doc, err := h.docRepo.GetByID(r.Context(), docID)
 	if err != nil {
 		utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", "Document not found")
 		return
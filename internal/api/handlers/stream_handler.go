@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"studyforge/pkg/ai"
+	"studyforge/pkg/utils"
+)
+
+// streamKeepAlive is how often an idle stream sends a comment so proxies
+// don't close it while a long chunk is generated
+const streamKeepAlive = 15 * time.Second
+
+// HandleGenerateStream generates study material like HandleGenerate, but
+// answers with a stream of Server-Sent Events:
+//
+//	progress  a step finished, e.g. text extracted or chunk 2/5 summarized
+//	delta     output text from providers that stream
+//	done      the response HandleGenerate would return, including content_id
+//	error     generation failed; carries code and message
+func (h *StudyHandler) HandleGenerateStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "STREAMING_UNSUPPORTED", "Streaming not supported")
+		return
+	}
+
+	// Invalid requests are answered with a plain JSON error before the stream opens
+	sessionID, req, ok := h.parseGenerateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &eventStream{w: w, flusher: flusher}
+	stopKeepAlive := stream.keepAlive(streamKeepAlive)
+
+	ctx := ai.WithDeltas(ai.WithListener(r.Context(), func(e ai.Event) {
+		stream.send(e.Type, e)
+	}))
+
+	result, err := h.studyService.Generate(ctx, sessionID, &req.GenerateRequest)
+	stopKeepAlive()
+
+	if err != nil {
+		_, errInfo := generateError(err)
+		stream.send("error", errInfo)
+		return
+	}
+	stream.send("done", result.Output)
+}
+
+// eventStream writes Server-Sent Events. Chunks are generated in parallel,
+// so writes are serialized.
+type eventStream struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+	failed  bool // the client went away; further writes are dropped
+}
+
+// send writes one event with a JSON payload
+func (s *eventStream) send(event string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", event, err)
+		return
+	}
+	s.write(fmt.Sprintf("event: %s\ndata: %s\n\n", event, data))
+}
+
+// write sends raw event text and flushes it to the client
+func (s *eventStream) write(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed {
+		return
+	}
+	if _, err := s.w.Write([]byte(text)); err != nil {
+		s.failed = true
+		return
+	}
+	s.flusher.Flush()
+}
+
+// keepAlive sends a comment every interval until the returned function is called
+func (s *eventStream) keepAlive(interval time.Duration) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.write(": keep-alive\n\n")
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -2,33 +2,36 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"studyforge/internal/services"
+	"studyforge/pkg/prompts"
 	"studyforge/pkg/utils"
 )
 
 // StudyHandler handles study material generation requests
 type StudyHandler struct {
 	studyService *services.StudyService
+	jobs         *services.JobQueue
 }
 
 // NewStudyHandler creates a new study handler
-func NewStudyHandler(studyService *services.StudyService) *StudyHandler {
+func NewStudyHandler(studyService *services.StudyService, jobs *services.JobQueue) *StudyHandler {
 	return &StudyHandler{
 		studyService: studyService,
+		jobs:         jobs,
 	}
 }
 
 // GenerateRequest represents a study material generation request
 type GenerateRequest struct {
-	DocumentID    int    `json:"document_id"`
-	PageStart     int    `json:"page_start"`
-	PageEnd       int    `json:"page_end"`
-	MaterialType  string `json:"material_type"`   // 'summary' for MVP
-	AcademicLevel string `json:"academic_level"`  // 'high_school', 'undergraduate', 'graduate'
+	services.GenerateRequest
+	Async bool `json:"async"` // queue a background job and return its ID instead of waiting
 }
 
 // HandleGenerate handles study material generation
@@ -38,70 +41,109 @@ func (h *StudyHandler) HandleGenerate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sessionID, req, ok := h.parseGenerateRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Async {
+		h.enqueueJob(w, r, sessionID, req)
+		return
+	}
+
+	result, err := h.studyService.Generate(r.Context(), sessionID, &req.GenerateRequest)
+	if err != nil {
+		status, errInfo := generateError(err)
+		utils.WriteError(w, status, errInfo.Code, errInfo.Message)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, result.Output)
+}
+
+// parseGenerateRequest reads and validates a generation request, writing an
+// error response and reporting false when it is invalid
+func (h *StudyHandler) parseGenerateRequest(w http.ResponseWriter, r *http.Request) (string, *GenerateRequest, bool) {
 	// Get session
 	session, err := utils.GetSessionFromContext(r.Context())
 	if err != nil {
 		utils.WriteError(w, http.StatusUnauthorized, "NO_SESSION", "No session found")
-		return
+		return "", nil, false
 	}
 
 	// Parse request body
 	var req GenerateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
-		return
+		return "", nil, false
 	}
 
 	// Validate request
 	if req.DocumentID <= 0 {
 		utils.WriteError(w, http.StatusBadRequest, "INVALID_DOCUMENT_ID", "Invalid document ID")
-		return
+		return "", nil, false
 	}
 	if req.PageStart < 1 || req.PageEnd < req.PageStart {
 		utils.WriteError(w, http.StatusBadRequest, "INVALID_PAGE_RANGE", "Invalid page range")
-		return
+		return "", nil, false
 	}
 
-	// MVP: Only support summaries
-	if req.MaterialType != "summary" && req.MaterialType != "" {
-		utils.WriteError(w, http.StatusBadRequest, "UNSUPPORTED_TYPE", "Only 'summary' type is supported in MVP")
-		return
-	}
-	req.MaterialType = "summary"
-
 	// Default academic level if not provided
 	if req.AcademicLevel == "" {
 		req.AcademicLevel = "undergraduate"
 	}
-
-	// Generate summary
-	serviceReq := &services.GenerateSummaryRequest{
-		SessionID:     session.ID,
-		DocumentID:    req.DocumentID,
-		PageStart:     req.PageStart,
-		PageEnd:       req.PageEnd,
-		AcademicLevel: req.AcademicLevel,
+	if req.Subject != "" && req.Subject != prompts.AutoSubject && !slices.Contains(h.studyService.Subjects(), req.Subject) {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_SUBJECT", "Unsupported subject: "+req.Subject)
+		return "", nil, false
+	}
+	if !slices.Contains(services.MaterialTypes, req.MaterialType) {
+		utils.WriteError(w, http.StatusBadRequest, "UNSUPPORTED_TYPE", "Unsupported material type: "+req.MaterialType)
+		return "", nil, false
+	}
+	if !validateMaterialOptions(w, &req.GenerateRequest) {
+		return "", nil, false
 	}
 
-	log.Printf("Generating summary for document %d, pages %d-%d", req.DocumentID, req.PageStart, req.PageEnd)
+	return session.ID, &req, true
+}
 
-	result, err := h.studyService.GenerateSummary(serviceReq)
-	if err != nil {
-		log.Printf("Failed to generate summary: %v", err)
-		utils.WriteError(w, http.StatusInternalServerError, "GENERATION_ERROR", err.Error())
-		return
+// validateMaterialOptions checks the options of the requested material
+// type, writing an error response and reporting false when one is invalid
+func validateMaterialOptions(w http.ResponseWriter, req *services.GenerateRequest) bool {
+	switch req.MaterialType {
+	case "quiz":
+		if req.QuestionCount < 0 || req.QuestionCount > services.MaxQuestionCount {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_QUESTION_COUNT",
+				fmt.Sprintf("Question count must be between 1 and %d", services.MaxQuestionCount))
+			return false
+		}
+		for _, t := range req.QuestionTypes {
+			if !slices.Contains(services.QuestionTypes, t) {
+				utils.WriteError(w, http.StatusBadRequest, "INVALID_QUESTION_TYPE", "Unsupported question type: "+t)
+				return false
+			}
+		}
+	case "essay_questions":
+		if req.QuestionCount < 0 || req.QuestionCount > services.MaxEssayCount {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_QUESTION_COUNT",
+				fmt.Sprintf("Question count must be between 1 and %d", services.MaxEssayCount))
+			return false
+		}
+	case "flashcards", "cloze":
+		if req.CardCount < 0 || req.CardCount > services.MaxCardCount {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_CARD_COUNT",
+				fmt.Sprintf("Card count must be between 1 and %d", services.MaxCardCount))
+			return false
+		}
 	}
+	return true
+}
 
-	log.Printf("Summary generated successfully (ID: %d)", result.ContentID)
-
-	// Return response
-	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
-		"content_id":      result.ContentID,
-		"material_type":   "summary",
-		"summary":         result.Summary,
-		"model_used":      result.ModelUsed,
-		"generation_time": result.GenerationTime,
-	})
+// generateError maps a generation failure to a response status and error
+func generateError(err error) (int, *utils.ErrorInfo) {
+	if errors.Is(err, services.ErrInvalidRequest) {
+		return http.StatusBadRequest, &utils.ErrorInfo{Code: "INVALID_REQUEST", Message: err.Error()}
+	}
+	log.Printf("Failed to generate study material: %v", err)
+	return http.StatusInternalServerError, &utils.ErrorInfo{Code: "GENERATION_ERROR", Message: err.Error()}
 }
 
 // HandleGetContent retrieves previously generated content
@@ -127,7 +169,7 @@ func (h *StudyHandler) HandleGetContent(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Get content
-	content, err := h.studyService.GetGeneratedContent(contentID, session.ID)
+	content, err := h.studyService.GetGeneratedContent(r.Context(), contentID, session.ID)
 	if err != nil {
 		log.Printf("Failed to get content: %v", err)
 		utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", "Content not found")
@@ -142,6 +184,18 @@ func (h *StudyHandler) HandleGetContent(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Flashcards live in their own table so edits are reflected here
+	if services.IsDeck(content.ContentType) {
+		cards, err := h.studyService.ListFlashcards(r.Context(), session.ID, content.ID, false)
+		if err != nil {
+			log.Printf("Failed to get flashcards: %v", err)
+			utils.WriteError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to load flashcards")
+			return
+		}
+		outputData["cards"] = cards
+		outputData["card_count"] = len(cards)
+	}
+
 	// Return content
 	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"content_id":      content.ID,
@@ -154,3 +208,46 @@ func (h *StudyHandler) HandleGetContent(w http.ResponseWriter, r *http.Request)
 		"created_at":      content.CreatedAt,
 	})
 }
+
+// HandleExportConceptMap renders a concept map as Graphviz DOT or Mermaid
+// GET /api/study/concept-map/export?id=N&format=dot|mermaid
+func (h *StudyHandler) HandleExportConceptMap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	// Get session
+	session, err := utils.GetSessionFromContext(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, "NO_SESSION", "No session found")
+		return
+	}
+
+	contentID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid content ID")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if !slices.Contains(services.ConceptMapFormats, format) {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_FORMAT", "Unsupported export format: "+format)
+		return
+	}
+
+	body, err := h.studyService.ExportConceptMap(r.Context(), contentID, session.ID, format)
+	if err != nil {
+		log.Printf("Failed to export concept map: %v", err)
+		utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", "Concept map not found")
+		return
+	}
+
+	contentType := "text/vnd.graphviz"
+	if format == "mermaid" {
+		contentType = "text/vnd.mermaid"
+	}
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(body))
+}
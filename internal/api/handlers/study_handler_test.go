@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"studyforge/internal/config"
+	"studyforge/internal/repository"
+	"studyforge/internal/services"
+	"studyforge/pkg/ai"
+	"studyforge/pkg/prompts"
+	"studyforge/pkg/utils"
+)
+
+// testPages is the text of the test PDF, one slice of lines per page
+var testPages = [][]string{
+	{
+		"The Treaty of Tordesillas was signed in 1494 by Spain and Portugal.",
+		"It divided newly discovered lands outside Europe between the two kingdoms.",
+		"Pope Alexander VI had earlier issued a papal bull in 1493.",
+		"Christopher Columbus reached the Caribbean in 1492 sailing for Spain.",
+	},
+	{
+		"Hernan Cortes conquered the Aztec Empire between 1519 and 1521.",
+		"The conquest relied on alliances with the Tlaxcalans.",
+		"Smallpox devastated the population of Tenochtitlan.",
+		"The Columbian Exchange transferred crops and animals between hemispheres.",
+	},
+}
+
+// buildTestPDF writes a minimal PDF with one Helvetica text page per entry
+// in pages
+func buildTestPDF(pages [][]string) []byte {
+	objects := []string{"", "", "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"}
+	var kids []string
+	for _, lines := range pages {
+		var content strings.Builder
+		content.WriteString("BT /F1 11 Tf 50 750 Td 14 TL")
+		for _, line := range lines {
+			fmt.Fprintf(&content, " (%s) '", line)
+		}
+		content.WriteString(" ET")
+
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+		contentRef := len(objects)
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R /Resources << /Font << /F1 3 0 R >> >> >>", contentRef))
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objects)))
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// newTestServer wires the handlers to the mock provider and a temporary
+// SQLite database, and returns a server and a client that keeps the session
+// cookie
+func newTestServer(t *testing.T) (*httptest.Server, *http.Client) {
+	t.Helper()
+	dir := t.TempDir()
+
+	db, err := repository.NewDatabase(filepath.Join(dir, "studyforge.db"))
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.RunMigrations("../../../migrations"); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+
+	cfg := &config.Config{
+		UploadDir:          filepath.Join(dir, "uploads"),
+		MaxFileSize:        10 * 1024 * 1024,
+		ChunkOverlapTokens: 40,
+		ChunkTokenizer:     "chars",
+	}
+	chains, err := ai.NewChains(ai.ChainConfig{Default: []string{ai.ProviderMock}}, ai.ProviderConfig{})
+	if err != nil {
+		t.Fatalf("NewChains: %v", err)
+	}
+	promptLibrary, err := prompts.Open("")
+	if err != nil {
+		t.Fatalf("prompts.Open: %v", err)
+	}
+
+	sessionRepo := repository.NewSessionRepository(db.DB)
+	docRepo := repository.NewDocumentRepository(db.DB)
+	contentRepo := repository.NewContentRepository(db.DB)
+	pdfService := services.NewPDFService(contentRepo)
+	studyService := services.NewStudyService(cfg, chains, promptLibrary, pdfService, contentRepo, docRepo,
+		repository.NewFlashcardRepository(db.DB), repository.NewGlossaryRepository(db.DB),
+		repository.NewPassageRepository(db.DB), repository.NewPromptTemplateRepository(db.DB))
+	jobQueue := services.NewJobQueue(repository.NewJobRepository(db.DB), 1, 1)
+
+	pdfHandler := NewPDFHandler(cfg, docRepo, pdfService)
+	studyHandler := NewStudyHandler(studyService, jobQueue)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/documents/upload", pdfHandler.HandleUpload)
+	mux.HandleFunc("/api/study/generate", studyHandler.HandleGenerate)
+	mux.HandleFunc("/api/study/content", studyHandler.HandleGetContent)
+
+	server := httptest.NewServer(utils.NewSessionManager(sessionRepo).Middleware(mux))
+	t.Cleanup(server.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookiejar.New: %v", err)
+	}
+	return server, &http.Client{Jar: jar}
+}
+
+// decodeData decodes the data of a successful API response into v
+func decodeData(t *testing.T, resp *http.Response, v interface{}) {
+	t.Helper()
+	defer resp.Body.Close()
+
+	var body struct {
+		Success bool             `json:"success"`
+		Data    json.RawMessage  `json:"data"`
+		Error   *utils.ErrorInfo `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !body.Success || resp.StatusCode != http.StatusOK {
+		t.Fatalf("request failed with status %d: %+v", resp.StatusCode, body.Error)
+	}
+	if err := json.Unmarshal(body.Data, v); err != nil {
+		t.Fatalf("failed to decode data: %v", err)
+	}
+}
+
+// uploadTestPDF uploads the test PDF and returns its document ID
+func uploadTestPDF(t *testing.T, server *httptest.Server, client *http.Client) int {
+	t.Helper()
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, err := writer.CreateFormFile("file", "history.pdf")
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	part.Write(buildTestPDF(testPages))
+	writer.Close()
+
+	resp, err := client.Post(server.URL+"/api/documents/upload", writer.FormDataContentType(), &form)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	var doc struct {
+		DocumentID int `json:"document_id"`
+		PageCount  int `json:"page_count"`
+	}
+	decodeData(t, resp, &doc)
+	if doc.PageCount != len(testPages) {
+		t.Fatalf("page_count = %d, want %d", doc.PageCount, len(testPages))
+	}
+	return doc.DocumentID
+}
+
+func TestGenerateAndFetchContent(t *testing.T) {
+	server, client := newTestServer(t)
+	documentID := uploadTestPDF(t, server, client)
+
+	tests := []struct {
+		material string
+		field    string // output field that must not be empty
+	}{
+		{"summary", "summary"},
+		{"notes", "markdown"},
+		{"flashcards", "cards"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.material, func(t *testing.T) {
+			body := fmt.Sprintf(`{"document_id":%d,"page_start":1,"page_end":2,"material_type":%q,"academic_level":"undergraduate"}`, documentID, tt.material)
+			resp, err := client.Post(server.URL+"/api/study/generate", "application/json", strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+			var generated map[string]interface{}
+			decodeData(t, resp, &generated)
+			if generated["provider"] != ai.ProviderMock {
+				t.Errorf("provider = %v, want %s", generated["provider"], ai.ProviderMock)
+			}
+			if isEmpty(generated[tt.field]) {
+				t.Fatalf("generated %s has no %s: %v", tt.material, tt.field, generated)
+			}
+			contentID := int(generated["content_id"].(float64))
+
+			resp, err = client.Get(fmt.Sprintf("%s/api/study/content?id=%d", server.URL, contentID))
+			if err != nil {
+				t.Fatalf("get content: %v", err)
+			}
+			var fetched struct {
+				ContentID    int                    `json:"content_id"`
+				MaterialType string                 `json:"material_type"`
+				Pages        string                 `json:"pages"`
+				ModelUsed    string                 `json:"model_used"`
+				Content      map[string]interface{} `json:"content"`
+			}
+			decodeData(t, resp, &fetched)
+			if fetched.ContentID != contentID || fetched.MaterialType != tt.material || fetched.Pages != "1-2" {
+				t.Errorf("unexpected content: %+v", fetched)
+			}
+			if !strings.HasPrefix(fetched.ModelUsed, ai.ProviderMock+":") {
+				t.Errorf("model_used = %q, want the mock provider", fetched.ModelUsed)
+			}
+			if isEmpty(fetched.Content[tt.field]) {
+				t.Errorf("stored %s has no %s: %v", tt.material, tt.field, fetched.Content)
+			}
+		})
+	}
+}
+
+func TestGetContentOfAnotherSession(t *testing.T) {
+	server, client := newTestServer(t)
+	documentID := uploadTestPDF(t, server, client)
+
+	body := fmt.Sprintf(`{"document_id":%d,"page_start":1,"page_end":1}`, documentID)
+	resp, err := client.Post(server.URL+"/api/study/generate", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	var generated struct {
+		ContentID int `json:"content_id"`
+	}
+	decodeData(t, resp, &generated)
+
+	// A client without the session cookie gets a new session
+	resp, err = http.Get(fmt.Sprintf("%s/api/study/content?id=%d", server.URL, generated.ContentID))
+	if err != nil {
+		t.Fatalf("get content: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+// isEmpty reports whether a decoded JSON value is missing or empty
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
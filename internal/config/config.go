@@ -1,37 +1,210 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+
+	"studyforge/pkg/ai"
 )
 
 // Config holds all application configuration
 type Config struct {
-	ServerPort       string
-	ServerHost       string
-	DatabasePath     string
-	UploadDir        string
-	MaxFileSize      int64
-	SessionTimeout   int
-	HuggingFaceKey   string
-	HuggingFaceURL   string
-	LogLevel         string
+	ServerPort            string
+	ServerHost            string
+	DatabasePath          string
+	UploadDir             string
+	MaxFileSize           int64
+	SessionTimeout        int
+	AIProvider            string
+	AIFailFast            bool
+	AIFallbackProviders   string // providers tried after AIProvider, comma separated
+	AIProviderChains      string // per-material chains, e.g. "quiz=ollama,extractive;summary=huggingface"
+	AIBreakerThreshold    int    // consecutive failures that open a provider's circuit
+	AIBreakerCooldown     int    // seconds a circuit stays open before a trial request
+	HuggingFaceKey        string
+	HuggingFaceURL        string
+	HuggingFaceModel      string
+	HuggingFaceGenModel   string
+	HuggingFaceEmbedModel string
+	HuggingFaceMaxInput   int // tokens of source text per request
+	HuggingFaceTimeout    int // seconds per request attempt
+	HuggingFaceMaxRetries int
+	HuggingFaceMaxWait    int // seconds, cap on any single retry wait
+	HuggingFaceWorkers    int
+	HuggingFaceRateLimit  float64 // requests per second
+	OpenAIBaseURL         string
+	OpenAIKey             string
+	OpenAIModel           string
+	OpenAIEmbedModel      string
+	OpenAITemperature     float64
+	OpenAIMaxTokens       int
+	OpenAIContextTokens   int
+	OpenAIConcurrency     int
+	OpenAIRateLimit       float64
+	OllamaURL             string
+	OllamaModel           string
+	OllamaEmbedModel      string
+	OllamaTemperature     float64
+	OllamaPullMissing     bool
+	OllamaContextTokens   int
+	OllamaConcurrency     int
+	ChunkOverlapTokens    int
+	ChunkTokenizer        string // "chars" or "words" token estimate
+	PromptsDir            string // prompt library directory; empty uses the built-in library
+	JobWorkers            int    // background generation jobs run at once
+	JobMaxAttempts        int    // runs of a job before it fails for good
+	LogLevel              string
 }
 
 // Load reads configuration from environment variables
 func Load() *Config {
 	return &Config{
-		ServerPort:       getEnv("SERVER_PORT", "8080"),
-		ServerHost:       getEnv("SERVER_HOST", "localhost"),
-		DatabasePath:     getEnv("DATABASE_PATH", "./data/studyforge.db"),
-		UploadDir:        getEnv("UPLOAD_DIR", "./uploads"),
-		MaxFileSize:      getEnvInt64("MAX_FILE_SIZE", 52428800), // 50MB
-		SessionTimeout:   getEnvInt("SESSION_TIMEOUT", 86400),     // 24 hours
-		HuggingFaceKey:   getEnv("HUGGINGFACE_API_KEY", ""),
-		HuggingFaceURL:   getEnv("HUGGINGFACE_API_URL", "https://api-inference.huggingface.co/models"),
-		LogLevel:         getEnv("LOG_LEVEL", "info"),
+		ServerPort:            getEnv("SERVER_PORT", "8080"),
+		ServerHost:            getEnv("SERVER_HOST", "localhost"),
+		DatabasePath:          getEnv("DATABASE_PATH", "./data/studyforge.db"),
+		UploadDir:             getEnv("UPLOAD_DIR", "./uploads"),
+		MaxFileSize:           getEnvInt64("MAX_FILE_SIZE", 52428800), // 50MB
+		SessionTimeout:        getEnvInt("SESSION_TIMEOUT", 86400),    // 24 hours
+		AIProvider:            getEnv("AI_PROVIDER", "huggingface"),
+		AIFailFast:            getEnvBool("AI_FAIL_FAST", false),
+		AIFallbackProviders:   getEnv("AI_FALLBACK_PROVIDERS", "extractive"),
+		AIProviderChains:      getEnv("AI_PROVIDER_CHAINS", ""),
+		AIBreakerThreshold:    getEnvInt("AI_BREAKER_THRESHOLD", 3),
+		AIBreakerCooldown:     getEnvInt("AI_BREAKER_COOLDOWN", 60),
+		HuggingFaceKey:        getEnv("HUGGINGFACE_API_KEY", ""),
+		HuggingFaceURL:        getEnv("HUGGINGFACE_API_URL", "https://api-inference.huggingface.co/models"),
+		HuggingFaceModel:      getEnv("HUGGINGFACE_MODEL", "facebook/bart-large-cnn"),
+		HuggingFaceGenModel:   getEnv("HUGGINGFACE_GENERATION_MODEL", "mistralai/Mistral-7B-Instruct-v0.2"),
+		HuggingFaceEmbedModel: getEnv("HUGGINGFACE_EMBEDDING_MODEL", "sentence-transformers/all-MiniLM-L6-v2"),
+		HuggingFaceMaxInput:   getEnvInt("HUGGINGFACE_MAX_INPUT_TOKENS", 800),
+		HuggingFaceTimeout:    getEnvInt("HUGGINGFACE_REQUEST_TIMEOUT", 30),
+		HuggingFaceMaxRetries: getEnvInt("HUGGINGFACE_MAX_RETRIES", 4),
+		HuggingFaceMaxWait:    getEnvInt("HUGGINGFACE_MAX_RETRY_WAIT", 60),
+		HuggingFaceWorkers:    getEnvInt("HUGGINGFACE_CONCURRENCY", 2),
+		HuggingFaceRateLimit:  getEnvFloat("HUGGINGFACE_RATE_LIMIT", 2),
+		OpenAIBaseURL:         getEnv("OPENAI_BASE_URL", "http://localhost:8000/v1"),
+		OpenAIKey:             getEnv("OPENAI_API_KEY", ""),
+		OpenAIModel:           getEnv("OPENAI_MODEL", ""),
+		OpenAIEmbedModel:      getEnv("OPENAI_EMBEDDING_MODEL", ""),
+		OpenAITemperature:     getEnvFloat("OPENAI_TEMPERATURE", 0.3),
+		OpenAIMaxTokens:       getEnvInt("OPENAI_MAX_TOKENS", 1024),
+		OpenAIContextTokens:   getEnvInt("OPENAI_CONTEXT_TOKENS", 8192),
+		OpenAIConcurrency:     getEnvInt("OPENAI_CONCURRENCY", 4),
+		OpenAIRateLimit:       getEnvFloat("OPENAI_RATE_LIMIT", 0),
+		OllamaURL:             getEnv("OLLAMA_URL", "http://localhost:11434"),
+		OllamaModel:           getEnv("OLLAMA_MODEL", "llama3.1"),
+		OllamaEmbedModel:      getEnv("OLLAMA_EMBEDDING_MODEL", "nomic-embed-text"),
+		OllamaTemperature:     getEnvFloat("OLLAMA_TEMPERATURE", 0.3),
+		OllamaPullMissing:     getEnvBool("OLLAMA_PULL_MISSING", false),
+		OllamaContextTokens:   getEnvInt("OLLAMA_CONTEXT_TOKENS", 4096),
+		OllamaConcurrency:     getEnvInt("OLLAMA_CONCURRENCY", 1),
+		ChunkOverlapTokens:    getEnvInt("CHUNK_OVERLAP_TOKENS", 40),
+		ChunkTokenizer:        getEnv("CHUNK_TOKENIZER", "chars"),
+		PromptsDir:            getEnv("PROMPTS_DIR", ""),
+		JobWorkers:            getEnvInt("JOB_WORKERS", 2),
+		JobMaxAttempts:        getEnvInt("JOB_MAX_ATTEMPTS", 3),
+		LogLevel:              getEnv("LOG_LEVEL", "info"),
+	}
+}
+
+// ProviderConfig builds the AI provider settings from the loaded configuration
+func (c *Config) ProviderConfig() ai.ProviderConfig {
+	return ai.ProviderConfig{
+		HuggingFace: ai.HuggingFaceConfig{
+			APIKey:          c.HuggingFaceKey,
+			BaseURL:         c.HuggingFaceURL,
+			Model:           c.HuggingFaceModel,
+			GenerationModel: c.HuggingFaceGenModel,
+			EmbeddingModel:  c.HuggingFaceEmbedModel,
+			MaxInputTokens:  c.HuggingFaceMaxInput,
+			RequestTimeout:  time.Duration(c.HuggingFaceTimeout) * time.Second,
+			Retry: ai.RetryPolicy{
+				MaxRetries: c.HuggingFaceMaxRetries,
+				BaseDelay:  500 * time.Millisecond,
+				MaxDelay:   time.Duration(c.HuggingFaceMaxWait) * time.Second,
+			},
+			Limits: ai.Limits{
+				Concurrency:       c.HuggingFaceWorkers,
+				RequestsPerSecond: c.HuggingFaceRateLimit,
+			},
+		},
+		OpenAI: ai.OpenAIConfig{
+			BaseURL:        c.OpenAIBaseURL,
+			APIKey:         c.OpenAIKey,
+			Model:          c.OpenAIModel,
+			EmbeddingModel: c.OpenAIEmbedModel,
+			Temperature:    c.OpenAITemperature,
+			MaxTokens:      c.OpenAIMaxTokens,
+			ContextTokens:  c.OpenAIContextTokens,
+			Limits: ai.Limits{
+				Concurrency:       c.OpenAIConcurrency,
+				RequestsPerSecond: c.OpenAIRateLimit,
+			},
+		},
+		Ollama: ai.OllamaConfig{
+			BaseURL:        c.OllamaURL,
+			Model:          c.OllamaModel,
+			EmbeddingModel: c.OllamaEmbedModel,
+			Temperature:    c.OllamaTemperature,
+			ContextTokens:  c.OllamaContextTokens,
+			PullMissing:    c.OllamaPullMissing,
+			Limits:         ai.Limits{Concurrency: c.OllamaConcurrency},
+		},
+	}
+}
+
+// chainMaterials lists the material types that can have a chain of their own
+var chainMaterials = []string{
+	"summary", "quiz", "flashcards", "notes", "timeline", "concept_map", "essay_questions",
+	"glossary", "ask", "custom_prompt",
+}
+
+// ChainConfig builds the per-material provider chains. Material types
+// without a chain of their own use AIProvider followed by AIFallbackProviders.
+func (c *Config) ChainConfig() (ai.ChainConfig, error) {
+	cfg := ai.ChainConfig{
+		Default:   append([]string{c.AIProvider}, splitList(c.AIFallbackProviders, ",")...),
+		Materials: make(map[string][]string),
+		Breaker: ai.BreakerConfig{
+			Threshold: c.AIBreakerThreshold,
+			Cooldown:  time.Duration(c.AIBreakerCooldown) * time.Second,
+		},
 	}
+
+	for _, entry := range splitList(c.AIProviderChains, ";") {
+		material, providers, ok := strings.Cut(entry, "=")
+		material = strings.TrimSpace(material)
+		if !ok || material == "" {
+			return cfg, fmt.Errorf("invalid AI_PROVIDER_CHAINS entry %q, expected material=provider,...", entry)
+		}
+		if !slices.Contains(chainMaterials, material) {
+			return cfg, fmt.Errorf("AI_PROVIDER_CHAINS names unknown material %q, expected one of %s",
+				material, strings.Join(chainMaterials, ", "))
+		}
+		chain := splitList(providers, ",")
+		if len(chain) == 0 {
+			return cfg, fmt.Errorf("AI_PROVIDER_CHAINS entry for %s lists no providers", material)
+		}
+		cfg.Materials[material] = chain
+	}
+
+	return cfg, nil
+}
+
+// splitList splits a separated list, dropping blank entries
+func splitList(s, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(s, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // getEnv retrieves environment variable or returns default
@@ -70,3 +243,31 @@ func getEnvInt64(key string, defaultValue int64) int64 {
 	}
 	return value
 }
+
+// getEnvFloat retrieves float64 environment variable or returns default
+func getEnvFloat(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		log.Printf("Invalid float for %s, using default: %g", key, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvBool retrieves boolean environment variable or returns default
+func getEnvBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		log.Printf("Invalid boolean for %s, using default: %t", key, defaultValue)
+		return defaultValue
+	}
+	return value
+}
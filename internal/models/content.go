@@ -13,6 +13,7 @@ type GeneratedContent struct {
 	OutputContent  string    `json:"output_content"`  // JSON string
 	AIModel        string    `json:"ai_model"`
 	GenerationTime int       `json:"generation_time"` // milliseconds
+	CacheKey       string    `json:"-"`               // hash of the generation inputs; empty when not cached
 	CreatedAt      time.Time `json:"created_at"`
 }
 
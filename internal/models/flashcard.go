@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Flashcard kinds
+const (
+	FlashcardTerm    = "term"
+	FlashcardPerson  = "person"
+	FlashcardDate    = "date"
+	FlashcardConcept = "concept"
+	FlashcardCloze   = "cloze" // front is a cloze deletion, back the full sentence
+)
+
+// Flashcard is a single card of a generated flashcard deck
+type Flashcard struct {
+	ID           int        `json:"id"`
+	ContentID    int        `json:"content_id"` // generated_content row of the deck
+	SessionID    string     `json:"-"`
+	DocumentID   int        `json:"document_id"`
+	Position     int        `json:"position"` // order within the deck
+	Front        string     `json:"front"`
+	Back         string     `json:"back"`
+	Kind         string     `json:"kind"` // 'term', 'person', 'date', 'concept', 'cloze'
+	SourcePage   int        `json:"source_page"`
+	Box          int        `json:"box"` // spaced repetition box, 0 = new or missed
+	ReviewCount  int        `json:"review_count"`
+	CorrectCount int        `json:"correct_count"`
+	LastReviewed *time.Time `json:"last_reviewed,omitempty"`
+	DueAt        time.Time  `json:"due_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
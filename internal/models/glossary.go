@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// GlossaryTerm is a defined term in a document's glossary
+type GlossaryTerm struct {
+	ID             int       `json:"id"`
+	DocumentID     int       `json:"document_id"`
+	Term           string    `json:"term"`
+	Definition     string    `json:"definition"`
+	DefinitionPage int       `json:"definition_page"` // page the definition was taken from
+	Pages          []int     `json:"pages"`           // every page the term appears on
+	AIModel        string    `json:"ai_model"`
+	CreatedAt      time.Time `json:"created_at"`
+}
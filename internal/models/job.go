@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Generation job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// GenerationJob is a generation request processed in the background
+type GenerationJob struct {
+	ID              int             `json:"id"`
+	SessionID       string          `json:"-"`
+	MaterialType    string          `json:"material_type"`
+	Request         json.RawMessage `json:"request"`
+	Status          string          `json:"status"`   // 'queued', 'running', 'succeeded', 'failed', 'cancelled'
+	Progress        int             `json:"progress"` // percent complete
+	ProgressMessage string          `json:"progress_message,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	Attempts        int             `json:"attempts"`
+	ContentID       int             `json:"content_id,omitempty"` // generated_content row, once succeeded
+	Result          json.RawMessage `json:"result,omitempty"`     // the response /api/study/generate would return
+	AvailableAt     time.Time       `json:"-"`
+	StartedAt       *time.Time      `json:"started_at,omitempty"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// Finished reports whether the job has stopped for good
+func (j *GenerationJob) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCancelled
+}
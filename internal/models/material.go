@@ -0,0 +1,78 @@
+package models
+
+// Quiz question types
+const (
+	QuestionMultipleChoice = "multiple_choice"
+	QuestionTrueFalse      = "true_false"
+	QuestionShortAnswer    = "short_answer"
+)
+
+// QuizQuestion is a single question of a generated quiz
+type QuizQuestion struct {
+	Type        string   `json:"type"`              // 'multiple_choice', 'true_false', 'short_answer'
+	Stem        string   `json:"stem"`              // question text
+	Options     []string `json:"options,omitempty"` // answer choices, empty for short answer
+	Answer      string   `json:"answer"`            // correct answer
+	Explanation string   `json:"explanation"`       // why the answer is correct
+	SourcePage  int      `json:"source_page"`       // page the question was drawn from
+}
+
+// EssayQuestion is an open-ended analytical prompt with its grading guide
+type EssayQuestion struct {
+	Prompt    string            `json:"prompt"`
+	KeyPoints []string          `json:"key_points"` // points a strong answer covers
+	Rubric    []RubricCriterion `json:"rubric"`
+	Pages     []int             `json:"pages"` // pages supporting an answer
+}
+
+// RubricCriterion is one graded criterion of an essay rubric
+type RubricCriterion struct {
+	Criterion   string `json:"criterion"`
+	Description string `json:"description"` // what earns full marks
+	Points      int    `json:"points"`
+}
+
+// NoteSection is a headed section of generated study notes
+type NoteSection struct {
+	Heading   string       `json:"heading"`
+	PageStart int          `json:"page_start"` // first page the section covers
+	PageEnd   int          `json:"page_end"`   // last page the section covers
+	Bullets   []NoteBullet `json:"bullets"`
+}
+
+// NoteBullet is a point in a note section, with optional sub-points
+type NoteBullet struct {
+	Text     string       `json:"text"`
+	KeyTerms []string     `json:"key_terms,omitempty"` // terms shown in bold
+	Children []NoteBullet `json:"children,omitempty"`
+}
+
+// TimelineEvent is a dated event extracted from source text
+type TimelineEvent struct {
+	Date         string   `json:"date"`      // date as written in the source
+	SortDate     string   `json:"sort_date"` // normalized sortable date, e.g. "1492-10-12" or "-0044"
+	Event        string   `json:"event"`
+	Participants []string `json:"participants,omitempty"`
+	Page         int      `json:"page"`
+}
+
+// ConceptMap is a graph of concepts and the relationships between them
+type ConceptMap struct {
+	Nodes []ConceptNode `json:"nodes"`
+	Edges []ConceptEdge `json:"edges"`
+}
+
+// ConceptNode is a concept in a concept map
+type ConceptNode struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Page  int    `json:"page"` // page the concept first appears on
+}
+
+// ConceptEdge is a labeled relationship between two concepts
+type ConceptEdge struct {
+	From  string `json:"from"` // source node ID
+	To    string `json:"to"`   // target node ID
+	Label string `json:"label"`
+	Page  int    `json:"page"`
+}
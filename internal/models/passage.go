@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Passage is an indexed slice of a document page used for retrieval
+type Passage struct {
+	ID             int       `json:"id"`
+	DocumentID     int       `json:"document_id"`
+	Page           int       `json:"page"`
+	Position       int       `json:"position"` // order within the page
+	Text           string    `json:"text"`
+	Embedding      []float64 `json:"-"` // nil when the provider could not embed it
+	EmbeddingModel string    `json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PromptTemplate is a saved custom prompt with {{text}}, {{level}} and
+// {{pages}} variables
+type PromptTemplate struct {
+	ID        int       `json:"id"`
+	SessionID string    `json:"-"`
+	Name      string    `json:"name"`
+	Template  string    `json:"template"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -17,13 +18,23 @@ func NewContentRepository(db *sql.DB) *ContentRepository {
 	return &ContentRepository{db: db}
 }
 
+// execer runs statements on the database or within a transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
 // CreateGenerated creates a new generated content record
-func (r *ContentRepository) CreateGenerated(content *models.GeneratedContent) error {
+func (r *ContentRepository) CreateGenerated(ctx context.Context, content *models.GeneratedContent) error {
+	return insertGenerated(ctx, r.db, content)
+}
+
+// insertGenerated inserts a generated content record with exec
+func insertGenerated(ctx context.Context, exec execer, content *models.GeneratedContent) error {
 	query := `
-		INSERT INTO generated_content (session_id, document_id, content_type, academic_level, input_pages, output_content, ai_model, generation_time, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO generated_content (session_id, document_id, content_type, academic_level, input_pages, output_content, ai_model, generation_time, cache_key, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := r.db.Exec(query,
+	result, err := exec.ExecContext(ctx, query,
 		content.SessionID,
 		content.DocumentID,
 		content.ContentType,
@@ -32,6 +43,7 @@ func (r *ContentRepository) CreateGenerated(content *models.GeneratedContent) er
 		content.OutputContent,
 		content.AIModel,
 		content.GenerationTime,
+		sql.NullString{String: content.CacheKey, Valid: content.CacheKey != ""},
 		content.CreatedAt,
 	)
 	if err != nil {
@@ -47,15 +59,48 @@ func (r *ContentRepository) CreateGenerated(content *models.GeneratedContent) er
 	return nil
 }
 
+// generatedColumns lists the columns read by scanGenerated, in order
+const generatedColumns = `id, session_id, document_id, content_type, academic_level, input_pages, output_content,
+	ai_model, generation_time, cache_key, created_at`
+
 // GetGeneratedByID retrieves generated content by ID
-func (r *ContentRepository) GetGeneratedByID(id int) (*models.GeneratedContent, error) {
+func (r *ContentRepository) GetGeneratedByID(ctx context.Context, id int) (*models.GeneratedContent, error) {
+	query := `SELECT ` + generatedColumns + ` FROM generated_content WHERE id = ?`
+	content, err := scanGenerated(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("content not found")
+		}
+		return nil, fmt.Errorf("failed to get content: %w", err)
+	}
+	return content, nil
+}
+
+// GetGeneratedByCacheKey retrieves a session's most recent content generated
+// from the inputs cacheKey was computed from
+func (r *ContentRepository) GetGeneratedByCacheKey(ctx context.Context, sessionID, cacheKey string) (*models.GeneratedContent, error) {
 	query := `
-		SELECT id, session_id, document_id, content_type, academic_level, input_pages, output_content, ai_model, generation_time, created_at
+		SELECT ` + generatedColumns + `
 		FROM generated_content
-		WHERE id = ?
+		WHERE session_id = ? AND cache_key = ?
+		ORDER BY id DESC
+		LIMIT 1
 	`
+	content, err := scanGenerated(r.db.QueryRowContext(ctx, query, sessionID, cacheKey))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // A cache miss is not an error
+		}
+		return nil, fmt.Errorf("failed to get cached content: %w", err)
+	}
+	return content, nil
+}
+
+// scanGenerated reads a row selected with generatedColumns
+func scanGenerated(row rowScanner) (*models.GeneratedContent, error) {
 	content := &models.GeneratedContent{}
-	err := r.db.QueryRow(query, id).Scan(
+	var cacheKey sql.NullString
+	err := row.Scan(
 		&content.ID,
 		&content.SessionID,
 		&content.DocumentID,
@@ -65,19 +110,18 @@ func (r *ContentRepository) GetGeneratedByID(id int) (*models.GeneratedContent,
 		&content.OutputContent,
 		&content.AIModel,
 		&content.GenerationTime,
+		&cacheKey,
 		&content.CreatedAt,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("content not found")
-		}
-		return nil, fmt.Errorf("failed to get content: %w", err)
+		return nil, err
 	}
+	content.CacheKey = cacheKey.String
 	return content, nil
 }
 
 // CreateExtracted creates a new extracted content record (cache)
-func (r *ContentRepository) CreateExtracted(content *models.ExtractedContent) error {
+func (r *ContentRepository) CreateExtracted(ctx context.Context, content *models.ExtractedContent) error {
 	query := `
 		INSERT INTO extracted_content (document_id, page_start, page_end, content, extraction_time, created_at)
 		VALUES (?, ?, ?, ?, ?, ?)
@@ -86,7 +130,7 @@ func (r *ContentRepository) CreateExtracted(content *models.ExtractedContent) er
 			extraction_time = excluded.extraction_time,
 			created_at = excluded.created_at
 	`
-	result, err := r.db.Exec(query,
+	result, err := r.db.ExecContext(ctx, query,
 		content.DocumentID,
 		content.PageStart,
 		content.PageEnd,
@@ -107,14 +151,14 @@ func (r *ContentRepository) CreateExtracted(content *models.ExtractedContent) er
 }
 
 // GetExtracted retrieves cached extracted content
-func (r *ContentRepository) GetExtracted(documentID, pageStart, pageEnd int) (*models.ExtractedContent, error) {
+func (r *ContentRepository) GetExtracted(ctx context.Context, documentID, pageStart, pageEnd int) (*models.ExtractedContent, error) {
 	query := `
 		SELECT id, document_id, page_start, page_end, content, extraction_time, created_at
 		FROM extracted_content
 		WHERE document_id = ? AND page_start = ? AND page_end = ?
 	`
 	content := &models.ExtractedContent{}
-	err := r.db.QueryRow(query, documentID, pageStart, pageEnd).Scan(
+	err := r.db.QueryRowContext(ctx, query, documentID, pageStart, pageEnd).Scan(
 		&content.ID,
 		&content.DocumentID,
 		&content.PageStart,
@@ -131,3 +175,38 @@ func (r *ContentRepository) GetExtracted(documentID, pageStart, pageEnd int) (*m
 	}
 	return content, nil
 }
+
+// GetExtractedByDocument retrieves every cached extraction for a document
+func (r *ContentRepository) GetExtractedByDocument(ctx context.Context, documentID int) ([]*models.ExtractedContent, error) {
+	query := `
+		SELECT id, document_id, page_start, page_end, content, extraction_time, created_at
+		FROM extracted_content
+		WHERE document_id = ?
+		ORDER BY page_start, page_end
+	`
+	rows, err := r.db.QueryContext(ctx, query, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get extracted content: %w", err)
+	}
+	defer rows.Close()
+
+	var contents []*models.ExtractedContent
+	for rows.Next() {
+		content := &models.ExtractedContent{}
+		err := rows.Scan(
+			&content.ID,
+			&content.DocumentID,
+			&content.PageStart,
+			&content.PageEnd,
+			&content.Content,
+			&content.ExtractionTime,
+			&content.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan extracted content: %w", err)
+		}
+		contents = append(contents, content)
+	}
+
+	return contents, rows.Err()
+}
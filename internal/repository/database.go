@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -44,20 +45,64 @@ func NewDatabase(dbPath string) (*Database, error) {
 	return &Database{DB: db}, nil
 }
 
-// RunMigrations executes database migrations
+// RunMigrations executes database migrations. Every .sql file in
+// migrationsPath is applied once, in filename order, and recorded in
+// schema_migrations.
 func (d *Database) RunMigrations(migrationsPath string) error {
 	log.Println("Running database migrations...")
 
-	// Read migration file
-	migrationFile := filepath.Join(migrationsPath, "001_init_schema.sql")
-	content, err := os.ReadFile(migrationFile)
+	if _, err := d.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	// Read migration files
+	files, err := filepath.Glob(filepath.Join(migrationsPath, "*.sql"))
 	if err != nil {
-		return fmt.Errorf("failed to read migration file: %w", err)
+		return fmt.Errorf("failed to list migration files: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no migration files found in %s", migrationsPath)
 	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := filepath.Base(file)
+
+		var applied int
+		if err := d.DB.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", version).Scan(&applied); err != nil {
+			return fmt.Errorf("failed to check migration %s: %w", version, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read migration file: %w", err)
+		}
 
-	// Execute migration
-	if _, err := d.DB.Exec(string(content)); err != nil {
-		return fmt.Errorf("failed to execute migration: %w", err)
+		// Execute migration
+		tx, err := d.DB.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %s: %w", version, err)
+		}
+		if _, err := tx.Exec(string(content)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to execute migration %s: %w", version, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %s: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %s: %w", version, err)
+		}
+		log.Printf("Applied migration %s", version)
 	}
 
 	log.Println("Migrations completed successfully")
@@ -1,13 +1,18 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"studyforge/internal/models"
 )
 
+// ErrDocumentNotFound is returned when a document does not exist
+var ErrDocumentNotFound = errors.New("document not found")
+
 // DocumentRepository handles document database operations
 type DocumentRepository struct {
 	db *sql.DB
@@ -19,12 +24,12 @@ func NewDocumentRepository(db *sql.DB) *DocumentRepository {
 }
 
 // Create creates a new document record
-func (r *DocumentRepository) Create(doc *models.Document) error {
+func (r *DocumentRepository) Create(ctx context.Context, doc *models.Document) error {
 	query := `
 		INSERT INTO documents (session_id, original_filename, stored_filename, file_path, file_size, page_count, upload_date)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := r.db.Exec(query,
+	result, err := r.db.ExecContext(ctx, query,
 		doc.SessionID,
 		doc.OriginalFilename,
 		doc.StoredFilename,
@@ -47,7 +52,7 @@ func (r *DocumentRepository) Create(doc *models.Document) error {
 }
 
 // GetByID retrieves a document by ID
-func (r *DocumentRepository) GetByID(id int) (*models.Document, error) {
+func (r *DocumentRepository) GetByID(ctx context.Context, id int) (*models.Document, error) {
 	query := `
 		SELECT id, session_id, original_filename, stored_filename, file_path, file_size, page_count, upload_date, last_accessed, is_deleted
 		FROM documents
@@ -56,7 +61,7 @@ func (r *DocumentRepository) GetByID(id int) (*models.Document, error) {
 	doc := &models.Document{}
 	var lastAccessed sql.NullTime
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&doc.ID,
 		&doc.SessionID,
 		&doc.OriginalFilename,
@@ -70,7 +75,7 @@ func (r *DocumentRepository) GetByID(id int) (*models.Document, error) {
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("document not found")
+			return nil, ErrDocumentNotFound
 		}
 		return nil, fmt.Errorf("failed to get document: %w", err)
 	}
@@ -83,14 +88,14 @@ func (r *DocumentRepository) GetByID(id int) (*models.Document, error) {
 }
 
 // GetBySessionID retrieves all documents for a session
-func (r *DocumentRepository) GetBySessionID(sessionID string) ([]*models.Document, error) {
+func (r *DocumentRepository) GetBySessionID(ctx context.Context, sessionID string) ([]*models.Document, error) {
 	query := `
 		SELECT id, session_id, original_filename, stored_filename, file_path, file_size, page_count, upload_date, last_accessed, is_deleted
 		FROM documents
 		WHERE session_id = ? AND is_deleted = FALSE
 		ORDER BY upload_date DESC
 	`
-	rows, err := r.db.Query(query, sessionID)
+	rows, err := r.db.QueryContext(ctx, query, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get documents: %w", err)
 	}
@@ -128,9 +133,9 @@ func (r *DocumentRepository) GetBySessionID(sessionID string) ([]*models.Documen
 }
 
 // UpdateLastAccessed updates the last accessed timestamp
-func (r *DocumentRepository) UpdateLastAccessed(id int) error {
+func (r *DocumentRepository) UpdateLastAccessed(ctx context.Context, id int) error {
 	query := `UPDATE documents SET last_accessed = ? WHERE id = ?`
-	_, err := r.db.Exec(query, time.Now(), id)
+	_, err := r.db.ExecContext(ctx, query, time.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to update last accessed: %w", err)
 	}
@@ -138,9 +143,9 @@ func (r *DocumentRepository) UpdateLastAccessed(id int) error {
 }
 
 // Delete marks a document as deleted
-func (r *DocumentRepository) Delete(id int) error {
+func (r *DocumentRepository) Delete(ctx context.Context, id int) error {
 	query := `UPDATE documents SET is_deleted = TRUE WHERE id = ?`
-	_, err := r.db.Exec(query, id)
+	_, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete document: %w", err)
 	}
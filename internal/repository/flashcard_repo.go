@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"studyforge/internal/models"
+)
+
+// FlashcardRepository handles flashcard database operations
+type FlashcardRepository struct {
+	db *sql.DB
+}
+
+// NewFlashcardRepository creates a new flashcard repository
+func NewFlashcardRepository(db *sql.DB) *FlashcardRepository {
+	return &FlashcardRepository{db: db}
+}
+
+const flashcardColumns = `id, content_id, session_id, document_id, position, front, back, kind, source_page,
+		box, review_count, correct_count, last_reviewed, due_at, created_at, updated_at`
+
+// CreateDeck stores a deck's generated content record and its cards in a
+// single transaction, so a failed insert never leaves a deck without cards.
+// Each card's ContentID is set to the new record's ID.
+func (r *FlashcardRepository) CreateDeck(ctx context.Context, content *models.GeneratedContent, cards []*models.Flashcard) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertGenerated(ctx, tx, content); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO flashcards (content_id, session_id, document_id, position, front, back, kind, source_page, due_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	for _, card := range cards {
+		card.ContentID = content.ID
+		result, err := tx.ExecContext(ctx, query,
+			card.ContentID,
+			card.SessionID,
+			card.DocumentID,
+			card.Position,
+			card.Front,
+			card.Back,
+			card.Kind,
+			card.SourcePage,
+			card.DueAt,
+			card.CreatedAt,
+			card.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create flashcard: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get flashcard ID: %w", err)
+		}
+		card.ID = int(id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit deck: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a flashcard by ID
+func (r *FlashcardRepository) GetByID(ctx context.Context, id int) (*models.Flashcard, error) {
+	query := `SELECT ` + flashcardColumns + ` FROM flashcards WHERE id = ?`
+
+	card, err := scanFlashcard(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("flashcard not found")
+		}
+		return nil, fmt.Errorf("failed to get flashcard: %w", err)
+	}
+	return card, nil
+}
+
+// GetByContentID retrieves the cards of a deck in order. When dueBefore is
+// non-zero only cards due by then are returned.
+func (r *FlashcardRepository) GetByContentID(ctx context.Context, contentID int, dueBefore time.Time) ([]*models.Flashcard, error) {
+	query := `SELECT ` + flashcardColumns + ` FROM flashcards WHERE content_id = ?`
+	args := []interface{}{contentID}
+	if !dueBefore.IsZero() {
+		query += ` AND due_at <= ?`
+		args = append(args, dueBefore)
+	}
+	query += ` ORDER BY position`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flashcards: %w", err)
+	}
+	defer rows.Close()
+
+	cards := []*models.Flashcard{}
+	for rows.Next() {
+		card, err := scanFlashcard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan flashcard: %w", err)
+		}
+		cards = append(cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get flashcards: %w", err)
+	}
+
+	return cards, nil
+}
+
+// Update saves the editable fields of a card
+func (r *FlashcardRepository) Update(ctx context.Context, card *models.Flashcard) error {
+	card.UpdatedAt = time.Now()
+
+	query := `UPDATE flashcards SET front = ?, back = ?, kind = ?, source_page = ?, updated_at = ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, card.Front, card.Back, card.Kind, card.SourcePage, card.UpdatedAt, card.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update flashcard: %w", err)
+	}
+	return nil
+}
+
+// UpdateReview saves the review state of a card
+func (r *FlashcardRepository) UpdateReview(ctx context.Context, card *models.Flashcard) error {
+	query := `
+		UPDATE flashcards SET box = ?, review_count = ?, correct_count = ?, last_reviewed = ?, due_at = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		card.Box,
+		card.ReviewCount,
+		card.CorrectCount,
+		card.LastReviewed,
+		card.DueAt,
+		card.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update flashcard review: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a flashcard
+func (r *FlashcardRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM flashcards WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete flashcard: %w", err)
+	}
+	return nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanFlashcard reads a row selected with flashcardColumns
+func scanFlashcard(row rowScanner) (*models.Flashcard, error) {
+	card := &models.Flashcard{}
+	var kind sql.NullString
+	var sourcePage sql.NullInt64
+	var lastReviewed sql.NullTime
+
+	err := row.Scan(
+		&card.ID,
+		&card.ContentID,
+		&card.SessionID,
+		&card.DocumentID,
+		&card.Position,
+		&card.Front,
+		&card.Back,
+		&kind,
+		&sourcePage,
+		&card.Box,
+		&card.ReviewCount,
+		&card.CorrectCount,
+		&lastReviewed,
+		&card.DueAt,
+		&card.CreatedAt,
+		&card.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	card.Kind = kind.String
+	card.SourcePage = int(sourcePage.Int64)
+	if lastReviewed.Valid {
+		card.LastReviewed = &lastReviewed.Time
+	}
+	return card, nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"studyforge/internal/models"
+)
+
+// GlossaryRepository handles document glossary database operations
+type GlossaryRepository struct {
+	db *sql.DB
+}
+
+// NewGlossaryRepository creates a new glossary repository
+func NewGlossaryRepository(db *sql.DB) *GlossaryRepository {
+	return &GlossaryRepository{db: db}
+}
+
+// GetProcessedPages returns the pages of a document already scanned for terms
+func (r *GlossaryRepository) GetProcessedPages(ctx context.Context, documentID int) (map[int]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT page FROM glossary_pages WHERE document_id = ?`, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get glossary pages: %w", err)
+	}
+	defer rows.Close()
+
+	pages := make(map[int]bool)
+	for rows.Next() {
+		var page int
+		if err := rows.Scan(&page); err != nil {
+			return nil, fmt.Errorf("failed to scan glossary page: %w", err)
+		}
+		pages[page] = true
+	}
+	return pages, rows.Err()
+}
+
+// GetTerms retrieves a document's glossary in alphabetical order, with the
+// pages each term appears on
+func (r *GlossaryRepository) GetTerms(ctx context.Context, documentID int) ([]*models.GlossaryTerm, error) {
+	query := `
+		SELECT id, document_id, term, definition, definition_page, ai_model, created_at
+		FROM glossary_terms
+		WHERE document_id = ?
+		ORDER BY term_key
+	`
+	rows, err := r.db.QueryContext(ctx, query, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get glossary: %w", err)
+	}
+	defer rows.Close()
+
+	terms := []*models.GlossaryTerm{}
+	byID := make(map[int]*models.GlossaryTerm)
+	for rows.Next() {
+		term := &models.GlossaryTerm{Pages: []int{}}
+		var definitionPage sql.NullInt64
+		err := rows.Scan(
+			&term.ID,
+			&term.DocumentID,
+			&term.Term,
+			&term.Definition,
+			&definitionPage,
+			&term.AIModel,
+			&term.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan glossary term: %w", err)
+		}
+		term.DefinitionPage = int(definitionPage.Int64)
+		terms = append(terms, term)
+		byID[term.ID] = term
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get glossary: %w", err)
+	}
+
+	occurrences, err := r.db.QueryContext(ctx, `
+		SELECT o.term_id, o.page
+		FROM glossary_occurrences o
+		JOIN glossary_terms t ON t.id = o.term_id
+		WHERE t.document_id = ?
+		ORDER BY o.term_id, o.page
+	`, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get glossary occurrences: %w", err)
+	}
+	defer occurrences.Close()
+
+	for occurrences.Next() {
+		var termID, page int
+		if err := occurrences.Scan(&termID, &page); err != nil {
+			return nil, fmt.Errorf("failed to scan glossary occurrence: %w", err)
+		}
+		if term, ok := byID[termID]; ok {
+			term.Pages = append(term.Pages, page)
+		}
+	}
+
+	return terms, occurrences.Err()
+}
+
+// Save stores new terms, adds page occurrences for all given terms and marks
+// pages as processed, in a single transaction. Terms with an ID of 0 are
+// inserted; a term already in the glossary under the same key is reused.
+func (r *GlossaryRepository) Save(ctx context.Context, documentID int, terms []*models.GlossaryTerm, processedPages []int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, term := range terms {
+		if term.ID == 0 {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO glossary_terms (document_id, term, term_key, definition, definition_page, ai_model, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT(document_id, term_key) DO NOTHING
+			`, documentID, term.Term, TermKey(term.Term), term.Definition, term.DefinitionPage, term.AIModel, term.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to create glossary term: %w", err)
+			}
+
+			err = tx.QueryRowContext(ctx,
+				`SELECT id FROM glossary_terms WHERE document_id = ? AND term_key = ?`,
+				documentID, TermKey(term.Term),
+			).Scan(&term.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get glossary term ID: %w", err)
+			}
+		}
+
+		for _, page := range term.Pages {
+			_, err := tx.ExecContext(ctx,
+				`INSERT OR IGNORE INTO glossary_occurrences (term_id, page) VALUES (?, ?)`,
+				term.ID, page,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to create glossary occurrence: %w", err)
+			}
+		}
+	}
+
+	for _, page := range processedPages {
+		_, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO glossary_pages (document_id, page) VALUES (?, ?)`,
+			documentID, page,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to mark glossary page: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit glossary: %w", err)
+	}
+	return nil
+}
+
+// TermKey normalizes a term for duplicate detection
+func TermKey(term string) string {
+	return strings.ToLower(strings.Join(strings.Fields(term), " "))
+}
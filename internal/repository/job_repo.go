@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"studyforge/internal/models"
+)
+
+// JobRepository handles generation job database operations
+type JobRepository struct {
+	db *sql.DB
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *sql.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// jobColumns lists the columns read by scanJob, in order
+const jobColumns = `id, session_id, material_type, request, status, progress, progress_message, error,
+	attempts, content_id, result, available_at, started_at, finished_at, created_at, updated_at`
+
+// Create queues a new job
+func (r *JobRepository) Create(ctx context.Context, job *models.GenerationJob) error {
+	now := time.Now()
+	job.Status = models.JobQueued
+	job.AvailableAt = now
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	query := `
+		INSERT INTO generation_jobs (session_id, material_type, request, status, available_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		job.SessionID,
+		job.MaterialType,
+		string(job.Request),
+		job.Status,
+		job.AvailableAt,
+		job.CreatedAt,
+		job.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get job ID: %w", err)
+	}
+	job.ID = int(id)
+	return nil
+}
+
+// GetByID retrieves a job by ID
+func (r *JobRepository) GetByID(ctx context.Context, id int) (*models.GenerationJob, error) {
+	query := `SELECT ` + jobColumns + ` FROM generation_jobs WHERE id = ?`
+	job, err := scanJob(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("job not found")
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+// GetBySession retrieves a session's most recent jobs, newest first
+func (r *JobRepository) GetBySession(ctx context.Context, sessionID string, limit int) ([]*models.GenerationJob, error) {
+	query := `SELECT ` + jobColumns + ` FROM generation_jobs WHERE session_id = ? ORDER BY id DESC LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, sessionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*models.GenerationJob{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// ClaimNext marks the oldest queued job that is due as running and returns
+// it, or nil when no job is waiting. The update is a single statement, so
+// concurrent workers never claim the same job.
+func (r *JobRepository) ClaimNext(ctx context.Context) (*models.GenerationJob, error) {
+	now := time.Now()
+	query := `
+		UPDATE generation_jobs
+		SET status = ?, attempts = attempts + 1, progress = 0, progress_message = NULL,
+			started_at = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM generation_jobs
+			WHERE status = ? AND available_at <= ?
+			ORDER BY available_at, id
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns
+	job, err := scanJob(r.db.QueryRowContext(ctx, query, models.JobRunning, now, now, models.JobQueued, now))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return job, nil
+}
+
+// UpdateProgress records the progress of a running job
+func (r *JobRepository) UpdateProgress(ctx context.Context, id, progress int, message string) error {
+	query := `UPDATE generation_jobs SET progress = ?, progress_message = ?, updated_at = ? WHERE id = ? AND status = ?`
+	if _, err := r.db.ExecContext(ctx, query, progress, message, time.Now(), id, models.JobRunning); err != nil {
+		return fmt.Errorf("failed to update job progress: %w", err)
+	}
+	return nil
+}
+
+// Succeed records the result of a running job
+func (r *JobRepository) Succeed(ctx context.Context, id, contentID int, result []byte) error {
+	now := time.Now()
+	query := `
+		UPDATE generation_jobs
+		SET status = ?, progress = 100, error = NULL, content_id = ?, result = ?, finished_at = ?, updated_at = ?
+		WHERE id = ? AND status = ?
+	`
+	if _, err := r.db.ExecContext(ctx, query, models.JobSucceeded, contentID, string(result), now, now, id, models.JobRunning); err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+// Retry returns a running job to the queue, to run again at availableAt
+func (r *JobRepository) Retry(ctx context.Context, id int, message string, availableAt time.Time) error {
+	query := `UPDATE generation_jobs SET status = ?, error = ?, available_at = ?, updated_at = ? WHERE id = ? AND status = ?`
+	if _, err := r.db.ExecContext(ctx, query, models.JobQueued, message, availableAt, time.Now(), id, models.JobRunning); err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+	return nil
+}
+
+// Fail records that a running job failed for good
+func (r *JobRepository) Fail(ctx context.Context, id int, message string) error {
+	now := time.Now()
+	query := `UPDATE generation_jobs SET status = ?, error = ?, finished_at = ?, updated_at = ? WHERE id = ? AND status = ?`
+	if _, err := r.db.ExecContext(ctx, query, models.JobFailed, message, now, now, id, models.JobRunning); err != nil {
+		return fmt.Errorf("failed to fail job: %w", err)
+	}
+	return nil
+}
+
+// Cancel cancels a job that has not finished, reporting whether it did
+func (r *JobRepository) Cancel(ctx context.Context, id int) (bool, error) {
+	now := time.Now()
+	query := `
+		UPDATE generation_jobs SET status = ?, finished_at = ?, updated_at = ?
+		WHERE id = ? AND status IN (?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, models.JobCancelled, now, now, id, models.JobQueued, models.JobRunning)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	return n > 0, nil
+}
+
+// RecoverInterrupted handles jobs left running by a server that stopped:
+// jobs with attempts to spare are queued again, the rest fail. It returns
+// the number of jobs requeued and failed.
+func (r *JobRepository) RecoverInterrupted(ctx context.Context, maxAttempts int) (int, int, error) {
+	now := time.Now()
+
+	requeued, err := r.db.ExecContext(ctx, `
+		UPDATE generation_jobs SET status = ?, available_at = ?, updated_at = ?
+		WHERE status = ? AND attempts < ?
+	`, models.JobQueued, now, now, models.JobRunning, maxAttempts)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to requeue interrupted jobs: %w", err)
+	}
+	failed, err := r.db.ExecContext(ctx, `
+		UPDATE generation_jobs SET status = ?, error = ?, finished_at = ?, updated_at = ?
+		WHERE status = ?
+	`, models.JobFailed, "interrupted by a server restart", now, now, models.JobRunning)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fail interrupted jobs: %w", err)
+	}
+
+	nRequeued, _ := requeued.RowsAffected()
+	nFailed, _ := failed.RowsAffected()
+	return int(nRequeued), int(nFailed), nil
+}
+
+// scanJob reads a row selected with jobColumns
+func scanJob(row rowScanner) (*models.GenerationJob, error) {
+	job := &models.GenerationJob{}
+	var request string
+	var progressMessage, jobError, result sql.NullString
+	var contentID sql.NullInt64
+	var startedAt, finishedAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID,
+		&job.SessionID,
+		&job.MaterialType,
+		&request,
+		&job.Status,
+		&job.Progress,
+		&progressMessage,
+		&jobError,
+		&job.Attempts,
+		&contentID,
+		&result,
+		&job.AvailableAt,
+		&startedAt,
+		&finishedAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Request = []byte(request)
+	job.ProgressMessage = progressMessage.String
+	job.Error = jobError.String
+	job.ContentID = int(contentID.Int64)
+	if result.Valid {
+		job.Result = []byte(result.String)
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return job, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"studyforge/internal/models"
+)
+
+// PassageRepository handles passage index database operations
+type PassageRepository struct {
+	db *sql.DB
+}
+
+// NewPassageRepository creates a new passage repository
+func NewPassageRepository(db *sql.DB) *PassageRepository {
+	return &PassageRepository{db: db}
+}
+
+// GetIndexedPages returns the pages of a document already split into passages
+func (r *PassageRepository) GetIndexedPages(ctx context.Context, documentID int) (map[int]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT page FROM passage_pages WHERE document_id = ?`, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get indexed pages: %w", err)
+	}
+	defer rows.Close()
+
+	pages := make(map[int]bool)
+	for rows.Next() {
+		var page int
+		if err := rows.Scan(&page); err != nil {
+			return nil, fmt.Errorf("failed to scan indexed page: %w", err)
+		}
+		pages[page] = true
+	}
+	return pages, rows.Err()
+}
+
+// GetByDocument retrieves every passage of a document in page order
+func (r *PassageRepository) GetByDocument(ctx context.Context, documentID int) ([]*models.Passage, error) {
+	query := `
+		SELECT id, document_id, page, position, text, embedding, embedding_model, created_at
+		FROM passages
+		WHERE document_id = ?
+		ORDER BY page, position
+	`
+	rows, err := r.db.QueryContext(ctx, query, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passages: %w", err)
+	}
+	defer rows.Close()
+
+	var passages []*models.Passage
+	for rows.Next() {
+		passage := &models.Passage{}
+		var embedding, embeddingModel sql.NullString
+		err := rows.Scan(
+			&passage.ID,
+			&passage.DocumentID,
+			&passage.Page,
+			&passage.Position,
+			&passage.Text,
+			&embedding,
+			&embeddingModel,
+			&passage.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan passage: %w", err)
+		}
+		if embedding.Valid {
+			if err := json.Unmarshal([]byte(embedding.String), &passage.Embedding); err != nil {
+				return nil, fmt.Errorf("failed to parse passage embedding: %w", err)
+			}
+			passage.EmbeddingModel = embeddingModel.String
+		}
+		passages = append(passages, passage)
+	}
+	return passages, rows.Err()
+}
+
+// Save stores new passages without embeddings and marks their pages as
+// indexed, in a single transaction
+func (r *PassageRepository) Save(ctx context.Context, documentID int, passages []*models.Passage, indexedPages []int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, passage := range passages {
+		// A passage already stored at this position is kept; no ID is returned for it
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO passages (document_id, page, position, text, created_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(document_id, page, position) DO NOTHING
+			RETURNING id
+		`, documentID, passage.Page, passage.Position, passage.Text, passage.CreatedAt).Scan(&passage.ID)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to create passage: %w", err)
+		}
+	}
+
+	for _, page := range indexedPages {
+		_, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO passage_pages (document_id, page) VALUES (?, ?)`,
+			documentID, page,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to mark indexed page: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit passages: %w", err)
+	}
+	return nil
+}
+
+// UpdateEmbeddings stores the embeddings of existing passages, in a single
+// transaction
+func (r *PassageRepository) UpdateEmbeddings(ctx context.Context, passages []*models.Passage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, passage := range passages {
+		data, err := json.Marshal(passage.Embedding)
+		if err != nil {
+			return fmt.Errorf("failed to encode passage embedding: %w", err)
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE passages SET embedding = ?, embedding_model = ? WHERE id = ?`,
+			string(data), passage.EmbeddingModel, passage.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update passage embedding: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit passage embeddings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"studyforge/internal/models"
+)
+
+// ErrPromptTemplateNotFound is returned when a prompt template does not exist
+var ErrPromptTemplateNotFound = errors.New("prompt template not found")
+
+// PromptTemplateRepository handles prompt template database operations
+type PromptTemplateRepository struct {
+	db *sql.DB
+}
+
+// NewPromptTemplateRepository creates a new prompt template repository
+func NewPromptTemplateRepository(db *sql.DB) *PromptTemplateRepository {
+	return &PromptTemplateRepository{db: db}
+}
+
+// Create creates a new prompt template
+func (r *PromptTemplateRepository) Create(ctx context.Context, template *models.PromptTemplate) error {
+	query := `
+		INSERT INTO prompt_templates (session_id, name, template, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		template.SessionID,
+		template.Name,
+		template.Template,
+		template.CreatedAt,
+		template.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create prompt template: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get prompt template ID: %w", err)
+	}
+	template.ID = int(id)
+	return nil
+}
+
+// GetByID retrieves a prompt template by ID
+func (r *PromptTemplateRepository) GetByID(ctx context.Context, id int) (*models.PromptTemplate, error) {
+	query := `
+		SELECT id, session_id, name, template, created_at, updated_at
+		FROM prompt_templates
+		WHERE id = ?
+	`
+	template := &models.PromptTemplate{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&template.ID,
+		&template.SessionID,
+		&template.Name,
+		&template.Template,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPromptTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to get prompt template: %w", err)
+	}
+	return template, nil
+}
+
+// GetBySession retrieves a session's prompt templates ordered by name
+func (r *PromptTemplateRepository) GetBySession(ctx context.Context, sessionID string) ([]*models.PromptTemplate, error) {
+	query := `
+		SELECT id, session_id, name, template, created_at, updated_at
+		FROM prompt_templates
+		WHERE session_id = ?
+		ORDER BY name COLLATE NOCASE
+	`
+	rows, err := r.db.QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []*models.PromptTemplate{}
+	for rows.Next() {
+		template := &models.PromptTemplate{}
+		err := rows.Scan(
+			&template.ID,
+			&template.SessionID,
+			&template.Name,
+			&template.Template,
+			&template.CreatedAt,
+			&template.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prompt template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+	return templates, rows.Err()
+}
+
+// Update saves the name and text of a prompt template
+func (r *PromptTemplateRepository) Update(ctx context.Context, template *models.PromptTemplate) error {
+	query := `UPDATE prompt_templates SET name = ?, template = ?, updated_at = ? WHERE id = ?`
+	if _, err := r.db.ExecContext(ctx, query, template.Name, template.Template, template.UpdatedAt, template.ID); err != nil {
+		return fmt.Errorf("failed to update prompt template: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a prompt template
+func (r *PromptTemplateRepository) Delete(ctx context.Context, id int) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM prompt_templates WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete prompt template: %w", err)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -19,12 +20,12 @@ func NewSessionRepository(db *sql.DB) *SessionRepository {
 }
 
 // Create creates a new session
-func (r *SessionRepository) Create(session *models.Session) error {
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
 	query := `
 		INSERT INTO sessions (id, created_at, last_accessed, ip_address, user_agent, is_active)
 		VALUES (?, ?, ?, ?, ?, ?)
 	`
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, query,
 		session.ID,
 		session.CreatedAt,
 		session.LastAccessed,
@@ -39,14 +40,14 @@ func (r *SessionRepository) Create(session *models.Session) error {
 }
 
 // GetByID retrieves a session by ID
-func (r *SessionRepository) GetByID(id string) (*models.Session, error) {
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*models.Session, error) {
 	query := `
 		SELECT id, created_at, last_accessed, ip_address, user_agent, is_active
 		FROM sessions
 		WHERE id = ? AND is_active = TRUE
 	`
 	session := &models.Session{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&session.ID,
 		&session.CreatedAt,
 		&session.LastAccessed,
@@ -64,9 +65,9 @@ func (r *SessionRepository) GetByID(id string) (*models.Session, error) {
 }
 
 // UpdateLastAccessed updates the last accessed timestamp
-func (r *SessionRepository) UpdateLastAccessed(id string) error {
+func (r *SessionRepository) UpdateLastAccessed(ctx context.Context, id string) error {
 	query := `UPDATE sessions SET last_accessed = ? WHERE id = ?`
-	_, err := r.db.Exec(query, time.Now(), id)
+	_, err := r.db.ExecContext(ctx, query, time.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to update last accessed: %w", err)
 	}
@@ -74,9 +75,9 @@ func (r *SessionRepository) UpdateLastAccessed(id string) error {
 }
 
 // Deactivate deactivates a session
-func (r *SessionRepository) Deactivate(id string) error {
+func (r *SessionRepository) Deactivate(ctx context.Context, id string) error {
 	query := `UPDATE sessions SET is_active = FALSE WHERE id = ?`
-	_, err := r.db.Exec(query, id)
+	_, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to deactivate session: %w", err)
 	}
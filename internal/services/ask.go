@@ -0,0 +1,448 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"studyforge/internal/models"
+	"studyforge/internal/repository"
+	"studyforge/pkg/ai"
+	"studyforge/pkg/chunker"
+	"studyforge/pkg/extractive"
+	"studyforge/pkg/prompts"
+	"studyforge/pkg/retrieval"
+)
+
+// Document Q&A limits
+const (
+	DefaultAskPassages = 5
+	MaxAskPassages     = 10
+	MaxQuestionLength  = 1000
+
+	// passageTokens is the size of indexed passages
+	passageTokens = 200
+
+	// embeddingWeight is the share of a passage's score taken from embedding
+	// similarity when vectors are available; the rest is BM25
+	embeddingWeight = 0.5
+
+	// extractiveAnswerSentences is how many sentences an extractive answer uses
+	extractiveAnswerSentences = 2
+
+	// MaxAskIndexPages is the largest document whose pages are all extracted
+	// for a question without a page range
+	MaxAskIndexPages = 50
+)
+
+// ErrPageRangeRequired is returned for a question without a page range about
+// a large document none of whose pages have been extracted yet
+var ErrPageRangeRequired = errors.New("page range required")
+
+// notCoveredAnswer is returned when no passage matches the question
+const notCoveredAnswer = "The document does not appear to cover this question."
+
+// citationRe matches page citations such as "(p. 12)" or "pp. 12-13"
+var citationRe = regexp.MustCompile(`\bpp?\.\s*(\d+)(?:\s*[-–]\s*(\d+))?`)
+
+// AskRequest contains a question about a document
+type AskRequest struct {
+	SessionID  string
+	DocumentID int
+	Question   string
+	PageStart  int // optional page range to search, 0 for the whole document
+	PageEnd    int
+	TopK       int // passages to retrieve, defaults to DefaultAskPassages
+}
+
+// RetrievedPassage is a passage used to answer a question
+type RetrievedPassage struct {
+	Page  int     `json:"page"`
+	Text  string  `json:"text"`
+	Score float64 `json:"score"` // relevance between 0 and 1
+}
+
+// AskResponse contains an answer with the passages it is grounded in
+type AskResponse struct {
+	Question       string             `json:"question"`
+	Answer         string             `json:"answer"`
+	Citations      []int              `json:"citations"` // pages cited by the answer
+	Passages       []RetrievedPassage `json:"passages"`
+	Retrieval      string             `json:"retrieval"` // 'hybrid' or 'keyword'
+	GenerationTime int                `json:"generation_time"`
+	ModelUsed      string             `json:"model_used,omitempty"`
+}
+
+// Ask answers a question about a document. Pages are split into passages
+// and indexed on first use; the passages most relevant to the question are
+// retrieved and only those are passed to the provider.
+//
+// With a page range, only that range is extracted and searched. Without
+// one, the pages already extracted are searched; a document with none is
+// extracted whole if it has at most MaxAskIndexPages pages.
+func (s *StudyService) Ask(ctx context.Context, req *AskRequest) (*AskResponse, error) {
+	startTime := time.Now()
+
+	doc, err := s.docRepo.GetByID(ctx, req.DocumentID)
+	if errors.Is(err, repository.ErrDocumentNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if doc.SessionID != req.SessionID {
+		return nil, fmt.Errorf("%w: unauthorized access to document", ErrInvalidRequest)
+	}
+
+	if err := s.extractAskPages(ctx, doc, req); err != nil {
+		return nil, err
+	}
+	if err := s.indexPassages(ctx, doc.ID); err != nil {
+		return nil, err
+	}
+	indexed, err := s.passagesInRange(ctx, doc.ID, req.PageStart, req.PageEnd)
+	if err != nil {
+		return nil, err
+	}
+	query, embeddingModel, err := s.embedQuestion(ctx, doc.ID, indexed, req.Question)
+	if err != nil {
+		return nil, err
+	}
+
+	topK := req.TopK
+	if topK <= 0 {
+		topK = DefaultAskPassages
+	}
+	if topK > MaxAskPassages {
+		topK = MaxAskPassages
+	}
+
+	passages, mode := retrievePassages(indexed, req.Question, query, embeddingModel, topK)
+
+	response := &AskResponse{
+		Question:  req.Question,
+		Passages:  passages,
+		Retrieval: mode,
+		Citations: []int{},
+	}
+
+	if len(passages) == 0 {
+		response.Answer = notCoveredAnswer
+	} else {
+		modelInfo, err := s.generateWith(ctx, "ask", func(p ai.Provider) error {
+			if p.ModelInfo().Extractive {
+				response.Answer, response.Passages = extractiveAnswer(req.Question, passages), passages
+				return ctx.Err()
+			}
+			// Only the passages that fit the provider's input are used and returned
+			prompt, used, err := s.fitAskPrompt(req.Question, passages, p.ModelInfo().MaxInputTokens)
+			if err != nil {
+				return err
+			}
+			answer, err := p.Generate(ctx, prompt)
+			if err != nil {
+				return err
+			}
+			response.Answer, response.Passages = strings.TrimSpace(answer), used
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to answer question: %w", err)
+		}
+		response.Citations = citedPages(response.Answer, response.Passages)
+		response.ModelUsed = modelLabel(modelInfo, modelInfo.Generator())
+	}
+
+	response.GenerationTime = int(time.Since(startTime).Milliseconds())
+	return response, nil
+}
+
+// extractAskPages makes sure the pages a question searches are in the
+// extraction cache. An open-ended range is completed to the document's
+// first or last page.
+func (s *StudyService) extractAskPages(ctx context.Context, doc *models.Document, req *AskRequest) error {
+	if req.PageStart == 0 && req.PageEnd == 0 {
+		cached, err := s.contentRepo.GetExtractedByDocument(ctx, doc.ID)
+		if err != nil {
+			return err
+		}
+		if len(cached) > 0 {
+			return nil
+		}
+		if doc.PageCount > MaxAskIndexPages {
+			return fmt.Errorf("%w: document has %d pages and none extracted yet", ErrPageRangeRequired, doc.PageCount)
+		}
+	}
+
+	pageStart, pageEnd := req.PageStart, req.PageEnd
+	if pageStart == 0 {
+		pageStart = 1
+	}
+	if pageEnd == 0 {
+		pageEnd = doc.PageCount
+	}
+	if err := s.pdfService.ValidatePageRange(doc.FilePath, pageStart, pageEnd); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if _, _, err := s.pdfService.ExtractText(ctx, doc.ID, doc.FilePath, pageStart, pageEnd); err != nil {
+		return fmt.Errorf("failed to extract text: %w", err)
+	}
+	return nil
+}
+
+// indexPassages splits the cached pages of a document not yet indexed into
+// passages and stores them. Vectors are added separately by embedQuestion.
+func (s *StudyService) indexPassages(ctx context.Context, documentID int) error {
+	pages, err := s.cachedPages(ctx, documentID)
+	if err != nil {
+		return err
+	}
+	indexed, err := s.passageRepo.GetIndexedPages(ctx, documentID)
+	if err != nil {
+		return err
+	}
+
+	var newPages []int
+	for page := range pages {
+		if !indexed[page] {
+			newPages = append(newPages, page)
+		}
+	}
+	if len(newPages) == 0 {
+		return nil
+	}
+	sort.Ints(newPages)
+
+	now := time.Now()
+	var passages []*models.Passage
+	for _, page := range newPages {
+		chunks := chunker.Split(pages[page], chunker.Options{
+			MaxTokens: passageTokens,
+			Tokenizer: s.tokenizer,
+		})
+		for i, chunk := range chunks {
+			passages = append(passages, &models.Passage{
+				DocumentID: documentID,
+				Page:       page,
+				Position:   i + 1,
+				Text:       chunk.Text,
+				CreatedAt:  now,
+			})
+		}
+	}
+
+	log.Printf("Indexing document %d: %d passages from %d new pages", documentID, len(passages), len(newPages))
+	return s.passageRepo.Save(ctx, documentID, passages, newPages)
+}
+
+// passagesInRange returns the indexed passages of a document in a page range
+func (s *StudyService) passagesInRange(ctx context.Context, documentID, pageStart, pageEnd int) ([]*models.Passage, error) {
+	stored, err := s.passageRepo.GetByDocument(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var passages []*models.Passage
+	for _, p := range stored {
+		if inPageRange(p.Page, pageStart, pageEnd) {
+			passages = append(passages, p)
+		}
+	}
+	return passages, nil
+}
+
+// embedQuestion embeds the question with the first provider of the "ask"
+// chain that can, after embedding the passages that have no vector from
+// that provider's model, and returns the question's vector and the model.
+// When no provider can embed, the vector is nil and passages are retrieved
+// by keyword alone; stale passages are tried again on the next question.
+func (s *StudyService) embedQuestion(ctx context.Context, documentID int, passages []*models.Passage, question string) ([]float64, string, error) {
+	var query []float64
+	var stale []*models.Passage
+	var vectors [][]float64
+	info, err := s.generateWith(ctx, "ask", func(p ai.Provider) error {
+		embeddingModel := p.ModelInfo().EmbeddingModel
+		stale = stale[:0]
+		for _, passage := range passages {
+			if passage.Embedding == nil || passage.EmbeddingModel != embeddingModel {
+				stale = append(stale, passage)
+			}
+		}
+
+		texts := make([]string, len(stale))
+		for i, passage := range stale {
+			texts[i] = passage.Text
+		}
+		var err error
+		if vectors, err = ai.EmbedAll(ctx, p, texts); err != nil {
+			return err
+		}
+		query, err = p.Embed(ctx, question)
+		return err
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+		log.Printf("Embedding unavailable, document %d is searched by keyword only: %v", documentID, err)
+		return nil, "", nil
+	}
+
+	if len(stale) > 0 {
+		for i, passage := range stale {
+			passage.Embedding = vectors[i]
+			passage.EmbeddingModel = info.EmbeddingModel
+		}
+		log.Printf("Embedded %d passages of document %d with %s", len(stale), documentID, info.EmbeddingModel)
+		if err := s.passageRepo.UpdateEmbeddings(ctx, stale); err != nil {
+			return nil, "", err
+		}
+	}
+	return query, info.EmbeddingModel, nil
+}
+
+// retrievePassages returns the topK passages most relevant to question,
+// best first, and the retrieval mode used. Scores blend BM25 with embedding
+// similarity for passages embedded with the question's model.
+func retrievePassages(passages []*models.Passage, question string, query []float64, embeddingModel string, topK int) ([]RetrievedPassage, string) {
+	texts := make([]string, len(passages))
+	for i, p := range passages {
+		texts[i] = p.Text
+	}
+	scores := retrieval.Normalize(retrieval.NewIndex(texts).Scores(question))
+
+	mode := "keyword"
+	if query != nil {
+		for i, p := range passages {
+			if p.Embedding == nil || p.EmbeddingModel != embeddingModel {
+				continue
+			}
+			mode = "hybrid"
+			similarity := retrieval.Cosine(query, p.Embedding)
+			if similarity < 0 {
+				similarity = 0
+			}
+			scores[i] = (1-embeddingWeight)*scores[i] + embeddingWeight*similarity
+		}
+	}
+
+	var retrieved []RetrievedPassage
+	for _, i := range retrieval.Top(scores, topK) {
+		retrieved = append(retrieved, RetrievedPassage{
+			Page:  passages[i].Page,
+			Text:  passages[i].Text,
+			Score: scores[i],
+		})
+	}
+	return retrieved, mode
+}
+
+// inPageRange reports whether page is within a page range whose bounds are
+// 0 when open
+func inPageRange(page, pageStart, pageEnd int) bool {
+	return (pageStart == 0 || page >= pageStart) && (pageEnd == 0 || page <= pageEnd)
+}
+
+// fitAskPrompt builds the answer prompt from as many of the best passages as
+// fit within maxTokens, always keeping at least one
+func (s *StudyService) fitAskPrompt(question string, passages []RetrievedPassage, maxTokens int) (string, []RetrievedPassage, error) {
+	prompt, err := s.buildAskPrompt(question, passages)
+	for err == nil && maxTokens > 0 && len(passages) > 1 && s.tokenizer.CountTokens(prompt) > maxTokens {
+		passages = passages[:len(passages)-1]
+		prompt, err = s.buildAskPrompt(question, passages)
+	}
+	return prompt, passages, err
+}
+
+// buildAskPrompt asks the provider to answer from the retrieved passages only
+func (s *StudyService) buildAskPrompt(question string, passages []RetrievedPassage) (string, error) {
+	numbered := make([]string, len(passages))
+	for i, p := range passages {
+		numbered[i] = fmt.Sprintf("[%d] (page %d) %s", i+1, p.Page, strings.TrimSpace(p.Text))
+	}
+	return s.renderPrompt("ask", prompts.GeneralSubject, "", prompts.Data{
+		Text:     strings.Join(numbered, "\n\n"),
+		Question: question,
+	})
+}
+
+// extractiveAnswer answers with the retrieved sentences sharing the most
+// words with the question, each followed by its page citation
+func extractiveAnswer(question string, passages []RetrievedPassage) string {
+	queryWords := make(map[string]bool)
+	for _, w := range extractive.Words(question) {
+		queryWords[w] = true
+	}
+
+	type candidate struct {
+		text    string
+		page    int
+		overlap int
+	}
+	var candidates []candidate
+	for _, p := range passages {
+		for _, sentence := range chunker.SplitSentences(p.Text) {
+			seen := make(map[string]bool)
+			for _, w := range extractive.Words(sentence) {
+				if queryWords[w] {
+					seen[w] = true
+				}
+			}
+			if len(seen) > 0 {
+				candidates = append(candidates, candidate{text: sentence, page: p.Page, overlap: len(seen)})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return notCoveredAnswer
+	}
+
+	// Passages are in relevance order, so ties keep the better passage first
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].overlap > candidates[j].overlap })
+	if len(candidates) > extractiveAnswerSentences {
+		candidates = candidates[:extractiveAnswerSentences]
+	}
+
+	parts := make([]string, len(candidates))
+	for i, c := range candidates {
+		parts[i] = fmt.Sprintf("%s (p. %d)", c.text, c.page)
+	}
+	return strings.Join(parts, " ")
+}
+
+// citedPages returns the retrieved pages the answer cites, falling back to
+// every retrieved page when the answer cites none of them
+func citedPages(answer string, passages []RetrievedPassage) []int {
+	retrieved := make(map[int]bool)
+	for _, p := range passages {
+		retrieved[p.Page] = true
+	}
+
+	var pages []int
+	for _, m := range citationRe.FindAllStringSubmatch(answer, -1) {
+		start, _ := strconv.Atoi(m[1])
+		end := start
+		if m[2] != "" {
+			end, _ = strconv.Atoi(m[2])
+		}
+		for page := start; page <= end && page-start <= MaxAskPassages; page++ {
+			if retrieved[page] && !containsInt(pages, page) {
+				pages = append(pages, page)
+			}
+		}
+	}
+
+	if len(pages) == 0 {
+		for page := range retrieved {
+			pages = append(pages, page)
+		}
+	}
+	sort.Ints(pages)
+	return pages
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"studyforge/internal/models"
+	"studyforge/pkg/ai"
+)
+
+// generationInputs identifies a generation for the cache: output generated
+// from the same inputs, prompt version and model is reused
+type generationInputs struct {
+	Material      string
+	Text          string // extracted text of the page range
+	AcademicLevel string
+	Params        interface{} // material-specific parameters; must marshal to JSON deterministically
+}
+
+// cacheKey hashes the inputs together with the prompt version and the model
+// that generates from them
+func (s *StudyService) cacheKey(in generationInputs, info ai.ModelInfo) string {
+	// Params are plain structs, which always marshal
+	params, _ := json.Marshal(in.Params)
+
+	h := sha256.New()
+	for _, part := range []string{in.Material, in.Text, in.AcademicLevel, s.prompts.Version(), modelLabel(info, info.Model), string(params)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedContent returns the session's content generated earlier from the
+// same inputs by any provider of the material's chain, or nil. Output of an
+// earlier provider in the chain is preferred, so a summary from a fallback
+// is reused only until the primary provider has produced one.
+func (s *StudyService) cachedContent(ctx context.Context, sessionID string, in generationInputs) (*models.GeneratedContent, error) {
+	for _, p := range s.chains.For(in.Material) {
+		content, err := s.contentRepo.GetGeneratedByCacheKey(ctx, sessionID, s.cacheKey(in, p.ModelInfo()))
+		if err != nil || content != nil {
+			return content, err
+		}
+	}
+	return nil, nil
+}
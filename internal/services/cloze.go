@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"studyforge/internal/models"
+	"studyforge/pkg/extractive"
+)
+
+// clozeModel is recorded as the model of cloze decks, which are always
+// generated extractively
+const clozeModel = "extractive-cloze"
+
+// Cloze sentence limits
+const (
+	maxClozeDeletions      = 2
+	minClozeSentenceLength = 40
+	maxClozeSentenceLength = 300
+)
+
+// GenerateClozeRequest contains parameters for cloze card generation
+type GenerateClozeRequest struct {
+	SessionID     string
+	DocumentID    int
+	PageStart     int
+	PageEnd       int
+	AcademicLevel string
+	CardCount     int // maximum cards in the deck, defaults to DefaultCardCount
+}
+
+// GenerateCloze builds a deck of cloze deletion cards from factual sentences
+// in specified pages, blanking their key terms and dates. It never calls the
+// AI provider, so it works with any provider configured. Cards are stored
+// like flashcards and can be edited and reviewed the same way.
+func (s *StudyService) GenerateCloze(ctx context.Context, req *GenerateClozeRequest) (*GenerateFlashcardsResponse, error) {
+	startTime := time.Now()
+
+	text, err := s.loadPages(ctx, req.SessionID, req.DocumentID, req.PageStart, req.PageEnd)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	count := req.CardCount
+	if count <= 0 {
+		count = DefaultCardCount
+	}
+	if count > MaxCardCount {
+		count = MaxCardCount
+	}
+
+	cards := clozeCards(text, count)
+	if len(cards) == 0 {
+		return nil, fmt.Errorf("failed to generate cloze cards: no factual sentences found in the selected pages")
+	}
+
+	generationTime := int(time.Since(startTime).Milliseconds())
+
+	// Save the deck and its cards
+	generatedContent := &models.GeneratedContent{
+		SessionID:      req.SessionID,
+		DocumentID:     req.DocumentID,
+		ContentType:    "cloze",
+		AcademicLevel:  req.AcademicLevel,
+		InputPages:     pageRange(req.PageStart, req.PageEnd),
+		AIModel:        clozeModel,
+		GenerationTime: generationTime,
+	}
+	outputData := map[string]interface{}{
+		"pages":          pageRange(req.PageStart, req.PageEnd),
+		"academic_level": req.AcademicLevel,
+		"card_count":     len(cards),
+	}
+	if err := s.saveDeck(ctx, generatedContent, outputData, cards); err != nil {
+		return nil, err
+	}
+
+	return &GenerateFlashcardsResponse{
+		ContentID:      generatedContent.ID,
+		Cards:          cards,
+		GenerationTime: generationTime,
+		ModelUsed:      clozeModel,
+	}, nil
+}
+
+// clozeCards picks the sentences richest in key terms and dates and blanks
+// up to maxClozeDeletions of them in each. The front of a card is the cloze
+// text; the back is the full sentence. Cards keep document order.
+func clozeCards(text string, count int) []*models.Flashcard {
+	sentences := extractive.Sentences(text)
+
+	// Terms mentioned in each sentence, dates first since they make the
+	// most factual deletions
+	terms := extractive.KeyTerms(sentences, 0)
+	termsIn := make(map[int][]string)
+	for _, kind := range []string{extractive.TermDate, extractive.TermName} {
+		for _, t := range terms {
+			if t.Kind != kind {
+				continue
+			}
+			for _, i := range t.Sentences {
+				termsIn[i] = append(termsIn[i], t.Text)
+			}
+		}
+	}
+
+	type candidate struct {
+		sentence extractive.Sentence
+		cloze    string
+		answers  []string
+	}
+	var candidates []candidate
+	for _, s := range sentences {
+		if len(s.Text) < minClozeSentenceLength || len(s.Text) > maxClozeSentenceLength || s.Text[len(s.Text)-1] == '?' {
+			continue
+		}
+		cloze, answers := extractive.Cloze(s.Text, termsIn[s.Index], maxClozeDeletions)
+		if len(answers) == 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{sentence: s, cloze: cloze, answers: answers})
+	}
+
+	// Prefer sentences with more deletions, then restore document order
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].answers) > len(candidates[j].answers)
+	})
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].sentence.Index < candidates[j].sentence.Index
+	})
+
+	cards := make([]*models.Flashcard, 0, len(candidates))
+	for _, c := range candidates {
+		cards = append(cards, &models.Flashcard{
+			Front:      c.cloze,
+			Back:       c.sentence.Text,
+			Kind:       models.FlashcardCloze,
+			SourcePage: c.sentence.Page,
+		})
+	}
+	return cards
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"studyforge/internal/models"
+	"studyforge/pkg/ai"
+	"studyforge/pkg/extractive"
+	"studyforge/pkg/prompts"
+)
+
+// Concept map size limits
+const (
+	maxConceptNodes         = 40
+	extractiveConceptNodes  = 15
+	maxConceptLabelWords    = 6
+	conceptMapContentType   = "concept_map"
+	conceptMapFormatDOT     = "dot"
+	conceptMapFormatMermaid = "mermaid"
+)
+
+// ConceptMapFormats lists the supported concept map export formats
+var ConceptMapFormats = []string{conceptMapFormatDOT, conceptMapFormatMermaid}
+
+// GenerateConceptMapRequest contains parameters for concept map generation
+type GenerateConceptMapRequest struct {
+	SessionID     string
+	DocumentID    int
+	PageStart     int
+	PageEnd       int
+	AcademicLevel string
+	Subject       string
+}
+
+// GenerateConceptMapResponse contains the generated concept map
+type GenerateConceptMapResponse struct {
+	ContentID      int               `json:"content_id"`
+	Map            models.ConceptMap `json:"concept_map"`
+	Subject        string            `json:"subject"`
+	Provider       string            `json:"provider"`
+	GenerationTime int               `json:"generation_time"`
+	ModelUsed      string            `json:"model_used"`
+}
+
+// GenerateConceptMap builds a graph of the concepts in specified pages
+func (s *StudyService) GenerateConceptMap(ctx context.Context, req *GenerateConceptMapRequest) (*GenerateConceptMapResponse, error) {
+	startTime := time.Now()
+
+	text, err := s.loadPages(ctx, req.SessionID, req.DocumentID, req.PageStart, req.PageEnd)
+	if err != nil {
+		return nil, err
+	}
+	subject := s.resolveSubject(req.Subject, text)
+
+	var conceptMap models.ConceptMap
+	modelInfo, err := s.generateWith(ctx, "concept_map", func(p ai.Provider) error {
+		if p.ModelInfo().Extractive {
+			conceptMap = extractiveConceptMap(text)
+			return ctx.Err()
+		}
+		var err error
+		conceptMap, err = s.modelConceptMap(ctx, p, text, subject, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate concept map: %w", err)
+	}
+	if len(conceptMap.Edges) == 0 {
+		return nil, fmt.Errorf("failed to generate concept map: no related concepts found in the selected pages")
+	}
+
+	generationTime := int(time.Since(startTime).Milliseconds())
+
+	// Create output content structure
+	outputData := map[string]interface{}{
+		"nodes":          conceptMap.Nodes,
+		"edges":          conceptMap.Edges,
+		"pages":          pageRange(req.PageStart, req.PageEnd),
+		"academic_level": req.AcademicLevel,
+		"subject":        subject,
+	}
+	if !modelInfo.Extractive {
+		outputData["prompt_version"] = s.prompts.Version()
+	}
+
+	// Save to database
+	generatedContent := &models.GeneratedContent{
+		SessionID:      req.SessionID,
+		DocumentID:     req.DocumentID,
+		ContentType:    conceptMapContentType,
+		AcademicLevel:  req.AcademicLevel,
+		InputPages:     pageRange(req.PageStart, req.PageEnd),
+		AIModel:        modelLabel(modelInfo, modelInfo.Generator()),
+		GenerationTime: generationTime,
+	}
+	if err := s.saveContent(ctx, generatedContent, outputData); err != nil {
+		return nil, err
+	}
+
+	return &GenerateConceptMapResponse{
+		ContentID:      generatedContent.ID,
+		Map:            conceptMap,
+		Subject:        subject,
+		Provider:       modelInfo.Provider,
+		GenerationTime: generationTime,
+		ModelUsed:      modelLabel(modelInfo, modelInfo.Generator()),
+	}, nil
+}
+
+// ExportConceptMap renders a stored concept map as Graphviz DOT or Mermaid
+func (s *StudyService) ExportConceptMap(ctx context.Context, contentID int, sessionID, format string) (string, error) {
+	content, err := s.GetGeneratedContent(ctx, contentID, sessionID)
+	if err != nil {
+		return "", err
+	}
+	if content.ContentType != conceptMapContentType {
+		return "", fmt.Errorf("content %d is not a concept map", contentID)
+	}
+
+	var conceptMap models.ConceptMap
+	if err := json.Unmarshal([]byte(content.OutputContent), &conceptMap); err != nil {
+		return "", fmt.Errorf("failed to parse concept map: %w", err)
+	}
+
+	switch format {
+	case conceptMapFormatDOT:
+		return RenderDOT(conceptMap), nil
+	case conceptMapFormatMermaid:
+		return RenderMermaid(conceptMap), nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// modelConceptMap asks the provider for the concepts and relationships in
+// each chunk and merges them into one graph, joining nodes by label
+func (s *StudyService) modelConceptMap(ctx context.Context, p ai.Provider, text, subject string, req *GenerateConceptMapRequest) (models.ConceptMap, error) {
+	chunks := s.promptChunks(p, text)
+	if len(chunks) == 0 {
+		return models.ConceptMap{}, errNoText
+	}
+
+	prompts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		prompt, err := s.buildConceptMapPrompt(chunk.Text, subject, req.AcademicLevel)
+		if err != nil {
+			return models.ConceptMap{}, err
+		}
+		prompts[i] = prompt
+	}
+
+	graph := newConceptGraph()
+	err := generateJSON(ctx, p, prompts, func(i int, raw string) error {
+		var out models.ConceptMap
+		if err := decodeJSONOutput(raw, &out); err != nil {
+			return err
+		}
+
+		chunk := chunks[i]
+
+		// Node IDs are only meaningful within one response
+		labels := make(map[string]string)
+		for _, n := range out.Nodes {
+			if label := strings.TrimSpace(n.Label); label != "" {
+				labels[n.ID] = label
+				graph.node(label, clampPage(n.Page, chunk.PageStart, chunk.PageEnd, chunk.PageStart))
+			}
+		}
+		for _, e := range out.Edges {
+			from, to := labels[e.From], labels[e.To]
+			if from == "" || to == "" {
+				continue
+			}
+			graph.edge(from, to, e.Label, clampPage(e.Page, chunk.PageStart, chunk.PageEnd, chunk.PageStart))
+		}
+		return nil
+	})
+	if err != nil {
+		return models.ConceptMap{}, err
+	}
+
+	return graph.build(maxConceptNodes), nil
+}
+
+// buildConceptMapPrompt asks for the concepts of a chunk as a JSON graph
+func (s *StudyService) buildConceptMapPrompt(text, subject, academicLevel string) (string, error) {
+	return s.renderPrompt("concept_map", subject, academicLevel, prompts.Data{Text: text})
+}
+
+// extractiveConceptMap links the most frequent key terms that appear in the
+// same sentence, labeling each edge with the words between them
+func extractiveConceptMap(text string) models.ConceptMap {
+	sentences := extractive.Sentences(text)
+
+	var concepts []extractive.Term
+	for _, t := range extractive.KeyTerms(sentences, 0) {
+		if t.Kind == extractive.TermName {
+			concepts = append(concepts, t)
+		}
+		if len(concepts) == extractiveConceptNodes {
+			break
+		}
+	}
+
+	graph := newConceptGraph()
+	for _, c := range concepts {
+		graph.node(c.Text, c.FirstPage)
+	}
+
+	for _, s := range sentences {
+		// Concepts in the order they appear in the sentence
+		type mention struct {
+			label string
+			start int
+		}
+		var mentions []mention
+		for _, c := range concepts {
+			if idx := strings.Index(s.Text, c.Text); idx >= 0 {
+				mentions = append(mentions, mention{label: c.Text, start: idx})
+			}
+		}
+		if len(mentions) < 2 {
+			continue
+		}
+		sort.Slice(mentions, func(i, j int) bool { return mentions[i].start < mentions[j].start })
+
+		// Link neighbouring mentions, skipping ones nested in the previous
+		// concept such as "Spain" in "New Spain"
+		from := mentions[0]
+		for _, to := range mentions[1:] {
+			end := from.start + len(from.label)
+			if to.start < end {
+				continue
+			}
+			graph.edge(from.label, to.label, relationLabel(s.Text[end:to.start]), s.Page)
+			from = to
+		}
+	}
+
+	return graph.build(extractiveConceptNodes)
+}
+
+// relationLabel trims the text between two concepts into a short label
+func relationLabel(between string) string {
+	words := strings.FieldsFunc(between, func(r rune) bool {
+		return r == ' ' || r == ',' || r == ';' || r == ':'
+	})
+	for len(words) > 0 && isArticle(words[0]) {
+		words = words[1:]
+	}
+	for len(words) > 0 && isArticle(words[len(words)-1]) {
+		words = words[:len(words)-1]
+	}
+	if len(words) > maxConceptLabelWords {
+		words = words[:maxConceptLabelWords]
+	}
+	if len(words) == 0 {
+		return "related to"
+	}
+	return strings.Join(words, " ")
+}
+
+// isArticle reports whether word is an article
+func isArticle(word string) bool {
+	switch strings.ToLower(word) {
+	case "the", "a", "an":
+		return true
+	}
+	return false
+}
+
+// conceptGraph accumulates nodes and edges, merging duplicates by label
+type conceptGraph struct {
+	nodes []models.ConceptNode
+	edges []models.ConceptEdge
+	ids   map[string]string // lowercase label -> node ID
+	seen  map[string]bool   // "from->to" edges already added
+}
+
+func newConceptGraph() *conceptGraph {
+	return &conceptGraph{ids: make(map[string]string), seen: make(map[string]bool)}
+}
+
+// node adds a concept if it is new and returns its ID
+func (g *conceptGraph) node(label string, page int) string {
+	key := strings.ToLower(label)
+	if id, ok := g.ids[key]; ok {
+		return id
+	}
+	id := fmt.Sprintf("n%d", len(g.nodes)+1)
+	g.ids[key] = id
+	g.nodes = append(g.nodes, models.ConceptNode{ID: id, Label: label, Page: page})
+	return id
+}
+
+// edge adds a relationship between two concepts, keeping the first label
+// seen for each pair
+func (g *conceptGraph) edge(from, to, label string, page int) {
+	fromID, toID := g.node(from, page), g.node(to, page)
+	key := fromID + "->" + toID
+	if fromID == toID || g.seen[key] {
+		return
+	}
+	g.seen[key] = true
+
+	label = strings.TrimSpace(label)
+	if label == "" {
+		label = "related to"
+	}
+	g.edges = append(g.edges, models.ConceptEdge{From: fromID, To: toID, Label: label, Page: page})
+}
+
+// build returns the graph limited to the maxNodes best-connected nodes,
+// dropping nodes without edges
+func (g *conceptGraph) build(maxNodes int) models.ConceptMap {
+	degree := make(map[string]int)
+	for _, e := range g.edges {
+		degree[e.From]++
+		degree[e.To]++
+	}
+
+	var nodes []models.ConceptNode
+	for _, n := range g.nodes {
+		if degree[n.ID] > 0 {
+			nodes = append(nodes, n)
+		}
+	}
+	if len(nodes) > maxNodes {
+		// Keep the most connected nodes, in their original order
+		ranked := append([]models.ConceptNode(nil), nodes...)
+		sort.SliceStable(ranked, func(i, j int) bool { return degree[ranked[i].ID] > degree[ranked[j].ID] })
+		top := make(map[string]bool, maxNodes)
+		for _, n := range ranked[:maxNodes] {
+			top[n.ID] = true
+		}
+
+		var kept []models.ConceptNode
+		for _, n := range nodes {
+			if top[n.ID] {
+				kept = append(kept, n)
+			}
+		}
+		nodes = kept
+	}
+
+	keep := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		keep[n.ID] = true
+	}
+	var edges []models.ConceptEdge
+	for _, e := range g.edges {
+		if keep[e.From] && keep[e.To] {
+			edges = append(edges, e)
+		}
+	}
+
+	return models.ConceptMap{Nodes: nodes, Edges: edges}
+}
+
+// RenderDOT renders a concept map as a Graphviz DOT digraph
+func RenderDOT(m models.ConceptMap) string {
+	var b strings.Builder
+	b.WriteString("digraph concept_map {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+	for _, n := range m.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s];\n", n.ID, dotQuote(n.Label))
+	}
+	for _, e := range m.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", e.From, e.To, dotQuote(e.Label))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// RenderMermaid renders a concept map as a Mermaid flowchart
+func RenderMermaid(m models.ConceptMap) string {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for _, n := range m.Nodes {
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", n.ID, mermaidEscape(n.Label))
+	}
+	for _, e := range m.Edges {
+		fmt.Fprintf(&b, "  %s -->|\"%s\"| %s\n", e.From, mermaidEscape(e.Label), e.To)
+	}
+	return b.String()
+}
+
+// dotQuote quotes a string as a DOT ID
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// mermaidEscape escapes characters that end a quoted Mermaid label
+func mermaidEscape(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	s = strings.ReplaceAll(s, "\n", " ")
+	return s
+}
@@ -0,0 +1,459 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"studyforge/internal/models"
+	"studyforge/internal/repository"
+	"studyforge/pkg/ai"
+	"studyforge/pkg/chunker"
+	"studyforge/pkg/extractive"
+	"studyforge/pkg/prompts"
+)
+
+// Custom prompt limits
+const (
+	MaxCustomPromptLength = 1000
+	MaxTemplateLength     = 4000
+	MaxTemplateNameLength = 100
+)
+
+// Saved template variables
+const (
+	varText  = "{{text}}"
+	varLevel = "{{level}}"
+	varPages = "{{pages}}"
+)
+
+// ErrInvalidPrompt is wrapped by prompt and template validation errors
+var ErrInvalidPrompt = errors.New("invalid prompt")
+
+// ErrDuplicateTemplate is returned when a session already has a template
+// with the requested name
+var ErrDuplicateTemplate = errors.New("a prompt template with this name already exists")
+
+// templateVarRe matches a {{variable}} in a template
+var templateVarRe = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// injectionPatterns match attempts to override the instructions sent to the
+// provider or to extract them
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b.{0,40}\b(previous|prior|above|earlier|system|all)\b.{0,20}\b(instructions?|prompts?|rules|directions)\b`),
+	regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output)\b.{0,30}\b(system prompt|hidden instructions|your instructions)\b`),
+	regexp.MustCompile(`(?i)\byou are (now|no longer)\b`),
+	regexp.MustCompile(`(?im)<\|[a-z_]+\|>|\[/?INST\]|<</?SYS>>|^\s*#{2,}\s*(system|assistant)\b`),
+}
+
+// CustomPromptTypes lists the supported custom prompt types, each framed
+// by the library's custom_prompt template
+var CustomPromptTypes = []string{"question", "notes", "quiz", "flashcards", "explanation", "timeline", "custom"}
+
+// CustomPromptRequest contains a free-form prompt or saved template to apply
+// to a page range
+type CustomPromptRequest struct {
+	SessionID     string
+	DocumentID    int
+	PageStart     int
+	PageEnd       int
+	AcademicLevel string
+	Prompt        string // free-form prompt, framed by PromptType
+	PromptType    string // defaults to "custom"
+	TemplateID    int    // saved template to use instead of Prompt
+}
+
+// CustomPromptResponse contains the provider's response to a custom prompt
+type CustomPromptResponse struct {
+	ContentID      int    `json:"content_id"`
+	Response       string `json:"response"`
+	PromptType     string `json:"prompt_type"`
+	TemplateID     int    `json:"template_id,omitempty"`
+	GenerationTime int    `json:"generation_time"`
+	ModelUsed      string `json:"model_used"`
+}
+
+// RunCustomPrompt applies a free-form prompt or saved template to the text
+// of a page range. Text too long for the provider is processed in chunks
+// whose responses are then combined.
+func (s *StudyService) RunCustomPrompt(ctx context.Context, req *CustomPromptRequest) (*CustomPromptResponse, error) {
+	startTime := time.Now()
+
+	promptType := req.PromptType
+	if promptType == "" {
+		promptType = "custom"
+	}
+
+	// Resolve the template and the instruction it carries
+	var render promptRenderer
+	var instruction, templateName string
+	if req.TemplateID > 0 {
+		saved, err := s.getPromptTemplate(ctx, req.SessionID, req.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		templateName = saved.Name
+		instruction = strings.TrimSpace(templateVarRe.ReplaceAllString(saved.Template, " "))
+		render = func(text, pages string) (string, error) {
+			return renderTemplate(saved.Template, text, req.AcademicLevel, pages), nil
+		}
+	} else {
+		if !slices.Contains(CustomPromptTypes, promptType) {
+			return nil, fmt.Errorf("%w: unsupported prompt type: %s", ErrInvalidRequest, promptType)
+		}
+		instruction = req.Prompt
+		render = func(text, pages string) (string, error) {
+			return s.renderPrompt("custom_prompt", prompts.GeneralSubject, req.AcademicLevel, prompts.Data{
+				Text:       text,
+				Prompt:     req.Prompt,
+				PromptType: promptType,
+				Pages:      pages,
+			})
+		}
+	}
+
+	text, err := s.loadPages(ctx, req.SessionID, req.DocumentID, req.PageStart, req.PageEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := pageRange(req.PageStart, req.PageEnd)
+
+	var response string
+	modelInfo, err := s.generateWith(ctx, "custom_prompt", func(p ai.Provider) error {
+		if p.ModelInfo().Extractive {
+			response = extractiveCustomAnswer(instruction, text)
+			return ctx.Err()
+		}
+		var err error
+		response, err = s.modelCustomPrompt(ctx, p, render, instruction, text, pages)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run custom prompt: %w", err)
+	}
+	model := modelLabel(modelInfo, modelInfo.Generator())
+
+	generationTime := int(time.Since(startTime).Milliseconds())
+
+	// Create output content structure
+	outputData := map[string]interface{}{
+		"response":       response,
+		"prompt":         req.Prompt,
+		"prompt_type":    promptType,
+		"pages":          pages,
+		"academic_level": req.AcademicLevel,
+	}
+	if req.TemplateID > 0 {
+		outputData["template_id"] = req.TemplateID
+		outputData["template_name"] = templateName
+	}
+
+	// Save to database
+	generatedContent := &models.GeneratedContent{
+		SessionID:      req.SessionID,
+		DocumentID:     req.DocumentID,
+		ContentType:    "custom_prompt",
+		AcademicLevel:  req.AcademicLevel,
+		InputPages:     pages,
+		AIModel:        model,
+		GenerationTime: generationTime,
+	}
+	if err := s.saveContent(ctx, generatedContent, outputData); err != nil {
+		return nil, err
+	}
+
+	return &CustomPromptResponse{
+		ContentID:      generatedContent.ID,
+		Response:       response,
+		PromptType:     promptType,
+		TemplateID:     req.TemplateID,
+		GenerationTime: generationTime,
+		ModelUsed:      model,
+	}, nil
+}
+
+// promptRenderer renders a custom prompt around the text of a page range
+type promptRenderer func(text, pages string) (string, error)
+
+// modelCustomPrompt renders the prompt over chunks of the text sized to
+// leave room for the prompt itself, and combines the chunk responses
+func (s *StudyService) modelCustomPrompt(ctx context.Context, p ai.Provider, render promptRenderer, instruction, text, pages string) (string, error) {
+	maxTokens := p.ModelInfo().MaxInputTokens
+	if maxTokens <= 0 {
+		maxTokens = 750
+	}
+	frame, err := render("", pages)
+	if err != nil {
+		return "", err
+	}
+	budget := maxTokens - s.tokenizer.CountTokens(frame)
+	if budget < maxTokens/4 {
+		return "", fmt.Errorf("%w: prompt is too long for the model's input", ErrInvalidRequest)
+	}
+
+	chunks := chunker.Split(text, chunker.Options{
+		MaxTokens:     budget,
+		OverlapTokens: s.cfg.ChunkOverlapTokens,
+		Tokenizer:     s.tokenizer,
+		PageMarkers:   true,
+	})
+	if len(chunks) == 0 {
+		return "", errNoText
+	}
+
+	prompts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		prompt, err := render(chunk.Text, pageRange(chunk.PageStart, chunk.PageEnd))
+		if err != nil {
+			return "", err
+		}
+		prompts[i] = prompt
+	}
+	outputs, err := ai.GenerateAll(ctx, p, prompts)
+	if err != nil {
+		return "", err
+	}
+	if len(outputs) == 1 {
+		return strings.TrimSpace(outputs[0]), nil
+	}
+
+	// Combine the partial responses when they fit in a single request
+	log.Printf("Combining %d partial custom prompt responses", len(outputs))
+	combined := strings.Join(outputs, "\n\n")
+	combinePrompt, err := s.buildCombinePrompt(instruction, combined)
+	if err != nil {
+		return "", err
+	}
+	if s.tokenizer.CountTokens(combinePrompt) > maxTokens {
+		return strings.TrimSpace(combined), nil
+	}
+
+	response, err := p.Generate(ctx, combinePrompt)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(response), nil
+}
+
+// buildCombinePrompt asks the provider to merge the responses to the chunks
+// of a custom prompt
+func (s *StudyService) buildCombinePrompt(instruction, responses string) (string, error) {
+	return s.renderPrompt("custom_prompt_combine", prompts.GeneralSubject, "", prompts.Data{
+		Text:   responses,
+		Prompt: instruction,
+	})
+}
+
+// renderTemplate fills in the template variables in a single pass, so
+// variable-like text in the inserted values is left as is
+func renderTemplate(template, text, academicLevel, pages string) string {
+	return strings.NewReplacer(
+		varText, text,
+		varLevel, levelName(academicLevel),
+		varPages, pages,
+	).Replace(template)
+}
+
+// levelName returns the readable name of an academic level
+func levelName(academicLevel string) string {
+	switch academicLevel {
+	case "high_school":
+		return "high school"
+	case "graduate":
+		return "graduate"
+	default:
+		return "undergraduate"
+	}
+}
+
+// ValidateCustomPrompt checks a free-form prompt for size, control
+// characters and instruction-override attempts
+func ValidateCustomPrompt(prompt string) error {
+	if strings.TrimSpace(prompt) == "" {
+		return invalidPrompt("prompt is required")
+	}
+	if len(prompt) > MaxCustomPromptLength {
+		return invalidPrompt("prompt must be at most %d characters", MaxCustomPromptLength)
+	}
+	if strings.Contains(prompt, "{{") {
+		return invalidPrompt("prompt may not contain template variables; save it as a template instead")
+	}
+	return checkPromptText(prompt)
+}
+
+// ValidatePromptTemplate checks a template's name and text. Templates must
+// include {{text}} and may only use the {{text}}, {{level}} and {{pages}}
+// variables.
+func ValidatePromptTemplate(name, template string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return invalidPrompt("template name is required")
+	}
+	if len(name) > MaxTemplateNameLength {
+		return invalidPrompt("template name must be at most %d characters", MaxTemplateNameLength)
+	}
+	if strings.TrimSpace(template) == "" {
+		return invalidPrompt("template is required")
+	}
+	if len(template) > MaxTemplateLength {
+		return invalidPrompt("template must be at most %d characters", MaxTemplateLength)
+	}
+
+	for _, m := range templateVarRe.FindAllStringSubmatch(template, -1) {
+		switch "{{" + m[1] + "}}" {
+		case varText, varLevel, varPages:
+		default:
+			return invalidPrompt("unknown template variable %s; use {{text}}, {{level}} or {{pages}}", m[0])
+		}
+	}
+	if !strings.Contains(template, varText) {
+		return invalidPrompt("template must include {{text}}")
+	}
+	return checkPromptText(template)
+}
+
+// checkPromptText rejects control characters and instruction-override
+// attempts
+func checkPromptText(text string) error {
+	for _, r := range text {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' && r != '\r' {
+			return invalidPrompt("prompt contains control characters")
+		}
+	}
+	for _, re := range injectionPatterns {
+		if re.MatchString(text) {
+			return invalidPrompt("prompt contains instructions that try to override the system prompt")
+		}
+	}
+	return nil
+}
+
+// invalidPrompt returns a validation error wrapping ErrInvalidPrompt
+func invalidPrompt(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidPrompt, fmt.Sprintf(format, args...))
+}
+
+// ListPromptTemplates returns a session's saved prompt templates
+func (s *StudyService) ListPromptTemplates(ctx context.Context, sessionID string) ([]*models.PromptTemplate, error) {
+	return s.promptTemplateRepo.GetBySession(ctx, sessionID)
+}
+
+// CreatePromptTemplate validates and saves a new prompt template
+func (s *StudyService) CreatePromptTemplate(ctx context.Context, sessionID, name, template string) (*models.PromptTemplate, error) {
+	name = strings.TrimSpace(name)
+	if err := ValidatePromptTemplate(name, template); err != nil {
+		return nil, err
+	}
+	if err := s.checkTemplateName(ctx, sessionID, name, 0); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	saved := &models.PromptTemplate{
+		SessionID: sessionID,
+		Name:      name,
+		Template:  template,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.promptTemplateRepo.Create(ctx, saved); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// UpdatePromptTemplate renames or rewrites a template. Empty fields are left
+// unchanged.
+func (s *StudyService) UpdatePromptTemplate(ctx context.Context, sessionID string, id int, name, template string) (*models.PromptTemplate, error) {
+	saved, err := s.getPromptTemplate(ctx, sessionID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name = strings.TrimSpace(name); name != "" {
+		saved.Name = name
+	}
+	if strings.TrimSpace(template) != "" {
+		saved.Template = template
+	}
+	if err := ValidatePromptTemplate(saved.Name, saved.Template); err != nil {
+		return nil, err
+	}
+	if err := s.checkTemplateName(ctx, sessionID, saved.Name, saved.ID); err != nil {
+		return nil, err
+	}
+
+	saved.UpdatedAt = time.Now()
+	if err := s.promptTemplateRepo.Update(ctx, saved); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// DeletePromptTemplate removes a saved template
+func (s *StudyService) DeletePromptTemplate(ctx context.Context, sessionID string, id int) error {
+	if _, err := s.getPromptTemplate(ctx, sessionID, id); err != nil {
+		return err
+	}
+	return s.promptTemplateRepo.Delete(ctx, id)
+}
+
+// getPromptTemplate loads a template and verifies the session owns it
+func (s *StudyService) getPromptTemplate(ctx context.Context, sessionID string, id int) (*models.PromptTemplate, error) {
+	saved, err := s.promptTemplateRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrPromptTemplateNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if saved.SessionID != sessionID {
+		return nil, fmt.Errorf("%w: unauthorized access to prompt template", ErrInvalidRequest)
+	}
+	return saved, nil
+}
+
+// checkTemplateName returns ErrDuplicateTemplate when another template of
+// the session already uses name, ignoring case
+func (s *StudyService) checkTemplateName(ctx context.Context, sessionID, name string, exceptID int) error {
+	existing, err := s.promptTemplateRepo.GetBySession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	for _, t := range existing {
+		if t.ID != exceptID && strings.EqualFold(t.Name, name) {
+			return ErrDuplicateTemplate
+		}
+	}
+	return nil
+}
+
+// extractiveCustomAnswer answers a prompt without a model. An extractive
+// provider cannot follow instructions, so the sentences most related to
+// them are used, or the key sentences of the pages when none are.
+func extractiveCustomAnswer(instruction, text string) string {
+	var passages []RetrievedPassage
+	for _, page := range chunker.ParsePages(text) {
+		passages = append(passages, RetrievedPassage{Page: page.Number, Text: page.Text})
+	}
+	if answer := extractiveAnswer(instruction, passages); answer != notCoveredAnswer {
+		return answer
+	}
+
+	sentences := extractive.TopSentences(extractive.Sentences(text), extractiveAnswerSentences)
+	if len(sentences) == 0 {
+		return notCoveredAnswer
+	}
+	parts := make([]string, len(sentences))
+	for i, sentence := range sentences {
+		parts[i] = fmt.Sprintf("%s (p. %d)", sentence.Text, sentence.Page)
+	}
+	return strings.Join(parts, " ")
+}
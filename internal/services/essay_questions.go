@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"studyforge/internal/models"
+	"studyforge/pkg/ai"
+	"studyforge/pkg/extractive"
+	"studyforge/pkg/prompts"
+)
+
+// Essay question limits
+const (
+	DefaultEssayCount = 5
+	MaxEssayCount     = 20
+	maxEssayKeyPoints = 5
+)
+
+// essayLevel describes how demanding essay prompts are at an academic level
+type essayLevel struct {
+	single string                   // extractive prompt about one concept
+	pair   string                   // extractive prompt relating two concepts
+	rubric []models.RubricCriterion // default rubric
+}
+
+// essayLevels scales essay prompts from explanation at high school level to
+// critical evaluation at graduate level
+var essayLevels = map[string]essayLevel{
+	"high_school": {
+		single: "Explain the importance of %s. Use details from the reading to support your answer.",
+		pair:   "Describe how %s and %s are connected. Use examples from the reading to support your answer.",
+		rubric: []models.RubricCriterion{
+			{Criterion: "Understanding", Description: "Accurately explains the main ideas from the reading", Points: 4},
+			{Criterion: "Evidence", Description: "Supports each point with specific examples from the text", Points: 3},
+			{Criterion: "Organization", Description: "Presents ideas in a clear order with an introduction and conclusion", Points: 3},
+		},
+	},
+	"undergraduate": {
+		single: "Analyze the role of %s. What were its causes and consequences, and how does the reading support your interpretation?",
+		pair:   "Compare %s and %s. How does the reading connect them, and what does the connection reveal?",
+		rubric: []models.RubricCriterion{
+			{Criterion: "Thesis", Description: "States a clear, arguable thesis that answers the prompt", Points: 4},
+			{Criterion: "Analysis", Description: "Explains causes, consequences and connections rather than summarizing", Points: 4},
+			{Criterion: "Evidence", Description: "Uses specific, relevant evidence from the reading with page references", Points: 4},
+			{Criterion: "Organization", Description: "Develops the argument logically with clear paragraphs and transitions", Points: 3},
+		},
+	},
+	"graduate": {
+		single: "Critically evaluate the significance of %s. How might competing interpretations of the evidence in the reading differ, and which is most persuasive?",
+		pair:   "Assess the relationship between %s and %s. To what extent does the evidence in the reading support a causal link, and what alternative explanations remain?",
+		rubric: []models.RubricCriterion{
+			{Criterion: "Argument", Description: "Advances an original, well-qualified argument", Points: 5},
+			{Criterion: "Critical evaluation", Description: "Weighs the strength and limits of the evidence and competing interpretations", Points: 5},
+			{Criterion: "Synthesis", Description: "Integrates evidence from across the reading into a coherent account", Points: 4},
+			{Criterion: "Evidence", Description: "Cites precise evidence with page references", Points: 3},
+			{Criterion: "Writing", Description: "Writes with scholarly precision and structure", Points: 3},
+		},
+	},
+}
+
+// GenerateEssayQuestionsRequest contains parameters for essay question generation
+type GenerateEssayQuestionsRequest struct {
+	SessionID     string
+	DocumentID    int
+	PageStart     int
+	PageEnd       int
+	AcademicLevel string
+	Subject       string
+	QuestionCount int // defaults to DefaultEssayCount
+}
+
+// GenerateEssayQuestionsResponse contains the generated essay questions
+type GenerateEssayQuestionsResponse struct {
+	ContentID      int                    `json:"content_id"`
+	Questions      []models.EssayQuestion `json:"questions"`
+	Subject        string                 `json:"subject"`
+	Provider       string                 `json:"provider"`
+	GenerationTime int                    `json:"generation_time"`
+	ModelUsed      string                 `json:"model_used"`
+}
+
+// GenerateEssayQuestions generates open-ended prompts with rubrics from
+// specified pages
+func (s *StudyService) GenerateEssayQuestions(ctx context.Context, req *GenerateEssayQuestionsRequest) (*GenerateEssayQuestionsResponse, error) {
+	startTime := time.Now()
+
+	text, err := s.loadPages(ctx, req.SessionID, req.DocumentID, req.PageStart, req.PageEnd)
+	if err != nil {
+		return nil, err
+	}
+	subject := s.resolveSubject(req.Subject, text)
+
+	count := req.QuestionCount
+	if count <= 0 {
+		count = DefaultEssayCount
+	}
+	if count > MaxEssayCount {
+		count = MaxEssayCount
+	}
+	level := essayLevelFor(req.AcademicLevel)
+
+	var questions []models.EssayQuestion
+	modelInfo, err := s.generateWith(ctx, "essay_questions", func(p ai.Provider) error {
+		if p.ModelInfo().Extractive {
+			questions = extractiveEssayQuestions(text, level, count)
+			return ctx.Err()
+		}
+		var err error
+		questions, err = s.modelEssayQuestions(ctx, p, text, subject, req, level, count)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate essay questions: %w", err)
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("failed to generate essay questions: no topics could be drawn from the selected pages")
+	}
+
+	generationTime := int(time.Since(startTime).Milliseconds())
+
+	// Create output content structure
+	outputData := map[string]interface{}{
+		"questions":      questions,
+		"pages":          pageRange(req.PageStart, req.PageEnd),
+		"academic_level": req.AcademicLevel,
+		"subject":        subject,
+		"question_count": len(questions),
+	}
+	if !modelInfo.Extractive {
+		outputData["prompt_version"] = s.prompts.Version()
+	}
+
+	// Save to database
+	generatedContent := &models.GeneratedContent{
+		SessionID:      req.SessionID,
+		DocumentID:     req.DocumentID,
+		ContentType:    "essay_questions",
+		AcademicLevel:  req.AcademicLevel,
+		InputPages:     pageRange(req.PageStart, req.PageEnd),
+		AIModel:        modelLabel(modelInfo, modelInfo.Generator()),
+		GenerationTime: generationTime,
+	}
+	if err := s.saveContent(ctx, generatedContent, outputData); err != nil {
+		return nil, err
+	}
+
+	return &GenerateEssayQuestionsResponse{
+		ContentID:      generatedContent.ID,
+		Questions:      questions,
+		Subject:        subject,
+		Provider:       modelInfo.Provider,
+		GenerationTime: generationTime,
+		ModelUsed:      modelLabel(modelInfo, modelInfo.Generator()),
+	}, nil
+}
+
+// essayLevelFor returns the essay settings for an academic level, defaulting to
+// undergraduate like audience
+func essayLevelFor(academicLevel string) essayLevel {
+	if level, ok := essayLevels[academicLevel]; ok {
+		return level
+	}
+	return essayLevels["undergraduate"]
+}
+
+// modelEssayQuestions asks the provider for essay prompts, spreading the
+// requested count across chunks of the source
+func (s *StudyService) modelEssayQuestions(ctx context.Context, p ai.Provider, text, subject string, req *GenerateEssayQuestionsRequest, level essayLevel, count int) ([]models.EssayQuestion, error) {
+	chunks := s.promptChunks(p, text)
+	if len(chunks) == 0 {
+		return nil, errNoText
+	}
+
+	var prompts []string
+	var promptChunks []int
+	for c, n := range distribute(count, len(chunks)) {
+		if n == 0 {
+			continue
+		}
+		prompt, err := s.buildEssayPrompt(chunks[c].Text, subject, req.AcademicLevel, level, n)
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, prompt)
+		promptChunks = append(promptChunks, c)
+	}
+
+	var questions []models.EssayQuestion
+	err := generateJSON(ctx, p, prompts, func(i int, raw string) error {
+		var out struct {
+			Questions []models.EssayQuestion `json:"questions"`
+		}
+		if err := decodeJSONOutput(raw, &out); err != nil {
+			return err
+		}
+
+		chunk := chunks[promptChunks[i]]
+		for _, q := range out.Questions {
+			if normalizeEssayQuestion(&q, level, req.PageStart, req.PageEnd, chunk.PageStart) {
+				questions = append(questions, q)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(questions) > count {
+		questions = questions[:count]
+	}
+	log.Printf("Generated %d of %d requested essay questions", len(questions), count)
+	return questions, nil
+}
+
+// buildEssayPrompt asks for essay prompts with rubrics as JSON
+func (s *StudyService) buildEssayPrompt(text, subject, academicLevel string, level essayLevel, count int) (string, error) {
+	var rubric []string
+	for _, c := range level.rubric {
+		rubric = append(rubric, c.Criterion)
+	}
+
+	return s.renderPrompt("essay_questions", subject, academicLevel, prompts.Data{
+		Text:     text,
+		Count:    count,
+		Criteria: strings.Join(rubric, ", "),
+	})
+}
+
+// normalizeEssayQuestion cleans up a model-written essay question, filling
+// in the level's rubric when the model gave none. It reports false when the
+// question is unusable.
+func normalizeEssayQuestion(q *models.EssayQuestion, level essayLevel, pageStart, pageEnd, fallbackPage int) bool {
+	q.Prompt = strings.TrimSpace(q.Prompt)
+	if q.Prompt == "" {
+		return false
+	}
+
+	var points []string
+	for _, p := range q.KeyPoints {
+		if p = strings.TrimSpace(p); p != "" && len(points) < maxEssayKeyPoints {
+			points = append(points, p)
+		}
+	}
+	q.KeyPoints = points
+
+	var rubric []models.RubricCriterion
+	for _, c := range q.Rubric {
+		c.Criterion = strings.TrimSpace(c.Criterion)
+		c.Description = strings.TrimSpace(c.Description)
+		if c.Criterion == "" {
+			continue
+		}
+		if c.Points <= 0 {
+			c.Points = 1
+		}
+		rubric = append(rubric, c)
+	}
+	if len(rubric) == 0 {
+		rubric = level.rubric
+	}
+	q.Rubric = rubric
+
+	var pages []int
+	for _, p := range q.Pages {
+		if p >= pageStart && p <= pageEnd && !containsInt(pages, p) {
+			pages = append(pages, p)
+		}
+	}
+	if len(pages) == 0 {
+		pages = []int{fallbackPage}
+	}
+	sort.Ints(pages)
+	q.Pages = pages
+
+	return true
+}
+
+// extractiveEssayQuestions fills the level's prompt templates with the key
+// terms of the text, alternating prompts about one term with prompts about
+// two terms mentioned together. Key points are the most representative
+// sentences mentioning the terms.
+func extractiveEssayQuestions(text string, level essayLevel, count int) []models.EssayQuestion {
+	sentences := extractive.Sentences(text)
+
+	var terms []extractive.Term
+	for _, t := range extractive.KeyTerms(sentences, 0) {
+		if t.Kind == extractive.TermName && t.Count >= 2 {
+			terms = append(terms, t)
+		}
+	}
+
+	// Pairs of terms sharing a sentence, most shared first
+	type pair struct {
+		a, b   extractive.Term
+		shared []int
+	}
+	var pairs []pair
+	for i := range terms {
+		for j := i + 1; j < len(terms); j++ {
+			if shared := sharedSentences(terms[i], terms[j]); len(shared) > 0 {
+				pairs = append(pairs, pair{a: terms[i], b: terms[j], shared: shared})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return len(pairs[i].shared) > len(pairs[j].shared) })
+
+	// Alternate single-term and paired prompts until count is reached
+	var questions []models.EssayQuestion
+	for i := 0; len(questions) < count && (i < len(terms) || i < len(pairs)); i++ {
+		if i < len(terms) {
+			t := terms[i]
+			questions = append(questions, essayFromSentences(
+				fmt.Sprintf(level.single, t.Text), sentences, t.Sentences, level))
+		}
+		if i < len(pairs) && len(questions) < count {
+			p := pairs[i]
+			questions = append(questions, essayFromSentences(
+				fmt.Sprintf(level.pair, p.a.Text, p.b.Text), sentences, p.shared, level))
+		}
+	}
+
+	return questions
+}
+
+// essayFromSentences builds an essay question whose key points are the top
+// sentences among those given, and whose pages are every page they fall on
+func essayFromSentences(prompt string, sentences []extractive.Sentence, indexes []int, level essayLevel) models.EssayQuestion {
+	candidates := make([]extractive.Sentence, 0, len(indexes))
+	var pages []int
+	for _, i := range indexes {
+		candidates = append(candidates, sentences[i])
+		if !containsInt(pages, sentences[i].Page) {
+			pages = append(pages, sentences[i].Page)
+		}
+	}
+	sort.Ints(pages)
+
+	return models.EssayQuestion{
+		Prompt:    prompt,
+		KeyPoints: extractive.Texts(extractive.TopSentences(candidates, maxEssayKeyPoints)),
+		Rubric:    level.rubric,
+		Pages:     pages,
+	}
+}
+
+// sharedSentences returns the sentences mentioning both terms
+func sharedSentences(a, b extractive.Term) []int {
+	var shared []int
+	for _, i := range a.Sentences {
+		if containsInt(b.Sentences, i) {
+			shared = append(shared, i)
+		}
+	}
+	return shared
+}
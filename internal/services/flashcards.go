@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"studyforge/internal/models"
+	"studyforge/pkg/ai"
+	"studyforge/pkg/extractive"
+	"studyforge/pkg/prompts"
+)
+
+// Flashcard deck size limits
+const (
+	DefaultCardCount = 20
+	MaxCardCount     = 100
+)
+
+// FlashcardKinds lists the supported card kinds
+var FlashcardKinds = []string{
+	models.FlashcardTerm,
+	models.FlashcardPerson,
+	models.FlashcardDate,
+	models.FlashcardConcept,
+	models.FlashcardCloze,
+}
+
+// reviewIntervals is how long a card waits before its next review, by box.
+// A correct answer moves a card up one box; a miss sends it back to box 0.
+var reviewIntervals = []time.Duration{
+	0,
+	24 * time.Hour,
+	3 * 24 * time.Hour,
+	7 * 24 * time.Hour,
+	14 * 24 * time.Hour,
+	30 * 24 * time.Hour,
+}
+
+// GenerateFlashcardsRequest contains parameters for flashcard generation
+type GenerateFlashcardsRequest struct {
+	SessionID     string
+	DocumentID    int
+	PageStart     int
+	PageEnd       int
+	AcademicLevel string
+	Subject       string
+	CardCount     int // maximum cards in the deck, defaults to DefaultCardCount
+}
+
+// GenerateFlashcardsResponse contains the generated deck
+type GenerateFlashcardsResponse struct {
+	ContentID      int                 `json:"content_id"`
+	Cards          []*models.Flashcard `json:"cards"`
+	Subject        string              `json:"subject"`
+	Provider       string              `json:"provider"`
+	GenerationTime int                 `json:"generation_time"`
+	ModelUsed      string              `json:"model_used"`
+}
+
+// GenerateFlashcards generates a flashcard deck from specified pages. The
+// deck is a generated_content row; each card is stored as its own record.
+func (s *StudyService) GenerateFlashcards(ctx context.Context, req *GenerateFlashcardsRequest) (*GenerateFlashcardsResponse, error) {
+	startTime := time.Now()
+
+	text, err := s.loadPages(ctx, req.SessionID, req.DocumentID, req.PageStart, req.PageEnd)
+	if err != nil {
+		return nil, err
+	}
+	subject := s.resolveSubject(req.Subject, text)
+
+	count := req.CardCount
+	if count <= 0 {
+		count = DefaultCardCount
+	}
+	if count > MaxCardCount {
+		count = MaxCardCount
+	}
+
+	var cards []*models.Flashcard
+	modelInfo, err := s.generateWith(ctx, "flashcards", func(p ai.Provider) error {
+		if p.ModelInfo().Extractive {
+			cards = extractiveFlashcards(text, count)
+			return ctx.Err()
+		}
+		var err error
+		cards, err = s.modelFlashcards(ctx, p, text, subject, req, count)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate flashcards: %w", err)
+	}
+	if len(cards) == 0 {
+		return nil, fmt.Errorf("failed to generate flashcards: no key terms found in the selected pages")
+	}
+
+	generationTime := int(time.Since(startTime).Milliseconds())
+
+	// Save the deck and its cards
+	generatedContent := &models.GeneratedContent{
+		SessionID:      req.SessionID,
+		DocumentID:     req.DocumentID,
+		ContentType:    "flashcards",
+		AcademicLevel:  req.AcademicLevel,
+		InputPages:     pageRange(req.PageStart, req.PageEnd),
+		AIModel:        modelLabel(modelInfo, modelInfo.Generator()),
+		GenerationTime: generationTime,
+	}
+	outputData := map[string]interface{}{
+		"pages":          pageRange(req.PageStart, req.PageEnd),
+		"academic_level": req.AcademicLevel,
+		"subject":        subject,
+		"card_count":     len(cards),
+	}
+	if !modelInfo.Extractive {
+		outputData["prompt_version"] = s.prompts.Version()
+	}
+	if err := s.saveDeck(ctx, generatedContent, outputData, cards); err != nil {
+		return nil, err
+	}
+
+	return &GenerateFlashcardsResponse{
+		ContentID:      generatedContent.ID,
+		Cards:          cards,
+		Subject:        subject,
+		Provider:       modelInfo.Provider,
+		GenerationTime: generationTime,
+		ModelUsed:      modelLabel(modelInfo, modelInfo.Generator()),
+	}, nil
+}
+
+// saveDeck stores a deck's content record together with its cards
+func (s *StudyService) saveDeck(ctx context.Context, content *models.GeneratedContent, output interface{}, cards []*models.Flashcard) error {
+	if err := setOutput(content, output); err != nil {
+		return err
+	}
+
+	for i, card := range cards {
+		card.SessionID = content.SessionID
+		card.DocumentID = content.DocumentID
+		card.Position = i + 1
+		card.DueAt = content.CreatedAt
+		card.CreatedAt = content.CreatedAt
+		card.UpdatedAt = content.CreatedAt
+	}
+	if err := s.flashcardRepo.CreateDeck(ctx, content, cards); err != nil {
+		return fmt.Errorf("failed to save deck: %w", err)
+	}
+	return nil
+}
+
+// ListFlashcards returns the cards of a deck, optionally only those due
+// for review
+func (s *StudyService) ListFlashcards(ctx context.Context, sessionID string, contentID int, dueOnly bool) ([]*models.Flashcard, error) {
+	content, err := s.GetGeneratedContent(ctx, contentID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !IsDeck(content.ContentType) {
+		return nil, fmt.Errorf("content %d is not a flashcard deck", contentID)
+	}
+
+	var dueBefore time.Time
+	if dueOnly {
+		dueBefore = time.Now()
+	}
+	return s.flashcardRepo.GetByContentID(ctx, contentID, dueBefore)
+}
+
+// IsDeck reports whether generated content of contentType is a deck whose
+// cards are stored in the flashcards table
+func IsDeck(contentType string) bool {
+	return contentType == "flashcards" || contentType == "cloze"
+}
+
+// UpdateFlashcard edits the text of a card. Empty fields are left unchanged.
+func (s *StudyService) UpdateFlashcard(ctx context.Context, sessionID string, id int, front, back, kind string) (*models.Flashcard, error) {
+	card, err := s.getFlashcard(ctx, sessionID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if front = strings.TrimSpace(front); front != "" {
+		card.Front = front
+	}
+	if back = strings.TrimSpace(back); back != "" {
+		card.Back = back
+	}
+	if kind != "" {
+		card.Kind = kind
+	}
+
+	if err := s.flashcardRepo.Update(ctx, card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// DeleteFlashcard removes a card from its deck
+func (s *StudyService) DeleteFlashcard(ctx context.Context, sessionID string, id int) error {
+	if _, err := s.getFlashcard(ctx, sessionID, id); err != nil {
+		return err
+	}
+	return s.flashcardRepo.Delete(ctx, id)
+}
+
+// ReviewFlashcard records a review of a card and schedules the next one
+func (s *StudyService) ReviewFlashcard(ctx context.Context, sessionID string, id int, correct bool) (*models.Flashcard, error) {
+	card, err := s.getFlashcard(ctx, sessionID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	card.ReviewCount++
+	if correct {
+		card.CorrectCount++
+		if card.Box < len(reviewIntervals)-1 {
+			card.Box++
+		}
+	} else {
+		card.Box = 0
+	}
+	card.LastReviewed = &now
+	card.DueAt = now.Add(reviewIntervals[card.Box])
+
+	if err := s.flashcardRepo.UpdateReview(ctx, card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// getFlashcard loads a card and verifies the session owns it
+func (s *StudyService) getFlashcard(ctx context.Context, sessionID string, id int) (*models.Flashcard, error) {
+	card, err := s.flashcardRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if card.SessionID != sessionID {
+		return nil, fmt.Errorf("unauthorized access to flashcard")
+	}
+	return card, nil
+}
+
+// modelFlashcards asks the provider for cards from each chunk of the source
+// and merges cards with the same front
+func (s *StudyService) modelFlashcards(ctx context.Context, p ai.Provider, text, subject string, req *GenerateFlashcardsRequest, count int) ([]*models.Flashcard, error) {
+	chunks := s.promptChunks(p, text)
+	if len(chunks) == 0 {
+		return nil, errNoText
+	}
+
+	var prompts []string
+	var promptChunks []int
+	for c, n := range distribute(count, len(chunks)) {
+		if n == 0 {
+			continue
+		}
+		prompt, err := s.buildFlashcardPrompt(chunks[c].Text, subject, req.AcademicLevel, n)
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, prompt)
+		promptChunks = append(promptChunks, c)
+	}
+
+	var cards []*models.Flashcard
+	seen := make(map[string]bool)
+	err := generateJSON(ctx, p, prompts, func(i int, raw string) error {
+		var out struct {
+			Cards []models.Flashcard `json:"cards"`
+		}
+		if err := decodeJSONOutput(raw, &out); err != nil {
+			return err
+		}
+
+		chunk := chunks[promptChunks[i]]
+		for _, c := range out.Cards {
+			card := &models.Flashcard{
+				Front:      strings.TrimSpace(c.Front),
+				Back:       strings.TrimSpace(c.Back),
+				Kind:       strings.ToLower(strings.TrimSpace(c.Kind)),
+				SourcePage: clampPage(c.SourcePage, req.PageStart, req.PageEnd, chunk.PageStart),
+			}
+			key := strings.ToLower(card.Front)
+			if card.Front == "" || card.Back == "" || seen[key] {
+				continue
+			}
+			if !slices.Contains(FlashcardKinds, card.Kind) {
+				card.Kind = models.FlashcardTerm
+			}
+			seen[key] = true
+			cards = append(cards, card)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cards) > count {
+		cards = cards[:count]
+	}
+	log.Printf("Generated %d of %d requested flashcards", len(cards), count)
+	return cards, nil
+}
+
+// buildFlashcardPrompt asks for up to count cards as JSON
+func (s *StudyService) buildFlashcardPrompt(text, subject, academicLevel string, count int) (string, error) {
+	return s.renderPrompt("flashcards", subject, academicLevel, prompts.Data{Text: text, Count: count})
+}
+
+// extractiveFlashcards builds cards from the most frequent key terms, using
+// the first source sentence that mentions each term as the back
+func extractiveFlashcards(text string, count int) []*models.Flashcard {
+	sentences := extractive.Sentences(text)
+	terms := extractive.KeyTerms(sentences, 0)
+
+	var cards []*models.Flashcard
+	for _, term := range terms {
+		if len(cards) == count {
+			break
+		}
+
+		// Use the first sentence of reasonable length mentioning the term
+		var back *extractive.Sentence
+		for _, idx := range term.Sentences {
+			if n := len(sentences[idx].Text); n >= 40 && n <= 400 {
+				back = &sentences[idx]
+				break
+			}
+		}
+		if back == nil {
+			continue
+		}
+
+		card := &models.Flashcard{
+			Front:      term.Text,
+			Back:       back.Text,
+			Kind:       models.FlashcardTerm,
+			SourcePage: back.Page,
+		}
+		if term.Kind == extractive.TermDate {
+			card.Front = "What happened in " + term.Text + "?"
+			card.Kind = models.FlashcardDate
+		}
+		cards = append(cards, card)
+	}
+
+	return cards
+}
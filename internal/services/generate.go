@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"studyforge/internal/models"
+)
+
+// ErrInvalidRequest is wrapped by errors caused by a generation request
+// itself, such as a document the session cannot use or a bad page range;
+// repeating the request cannot fix them
+var ErrInvalidRequest = errors.New("invalid request")
+
+// MaterialTypes lists the material types Generate accepts; an empty type
+// means a summary
+var MaterialTypes = []string{"", "summary", "quiz", "flashcards", "notes", "timeline", "concept_map", "essay_questions", "cloze"}
+
+// GenerateRequest is a request for any material type, as sent to
+// /api/study/generate and stored with background jobs
+type GenerateRequest struct {
+	DocumentID    int    `json:"document_id"`
+	PageStart     int    `json:"page_start"`
+	PageEnd       int    `json:"page_end"`
+	MaterialType  string `json:"material_type"`  // 'summary', 'quiz', 'flashcards', 'notes', 'timeline', 'concept_map', 'essay_questions' or 'cloze'
+	AcademicLevel string `json:"academic_level"` // 'high_school', 'undergraduate', 'graduate'
+	Subject       string `json:"subject"`        // prompt subject, e.g. 'history' or 'biology'; 'auto' detects it, default 'general'
+
+	// Summary options
+	TargetLength int  `json:"target_length"` // optional max characters of the final summary
+	KeepSections bool `json:"keep_sections"` // also return per-section summaries
+	Regenerate   bool `json:"regenerate"`    // generate again instead of returning a cached summary of the same inputs
+
+	// Quiz and essay question options
+	QuestionCount int      `json:"question_count"` // number of questions, default 10 (5 for essay questions)
+	QuestionTypes []string `json:"question_types"` // mix of 'multiple_choice', 'true_false', 'short_answer'
+
+	// Flashcard and cloze options
+	CardCount int `json:"card_count"` // maximum cards in the deck, default 20
+}
+
+// GenerateResponse is the outcome of a generation request
+type GenerateResponse struct {
+	ContentID    int
+	MaterialType string                 // the requested type, 'summary' when none was given
+	Output       map[string]interface{} // the response data of /api/study/generate
+}
+
+// Generate generates the requested material type for a session
+func (s *StudyService) Generate(ctx context.Context, sessionID string, req *GenerateRequest) (*GenerateResponse, error) {
+	materialType := req.MaterialType
+	if materialType == "" {
+		materialType = "summary"
+	}
+	log.Printf("Generating %s for document %d, pages %d-%d", materialType, req.DocumentID, req.PageStart, req.PageEnd)
+
+	var resp *GenerateResponse
+	var err error
+	switch materialType {
+	case "summary":
+		resp, err = s.generateSummaryOutput(ctx, sessionID, req)
+	case "quiz":
+		resp, err = s.generateQuizOutput(ctx, sessionID, req)
+	case "flashcards":
+		resp, err = s.generateFlashcardsOutput(ctx, sessionID, req)
+	case "notes":
+		resp, err = s.generateNotesOutput(ctx, sessionID, req)
+	case "timeline":
+		resp, err = s.generateTimelineOutput(ctx, sessionID, req)
+	case "concept_map":
+		resp, err = s.generateConceptMapOutput(ctx, sessionID, req)
+	case "essay_questions":
+		resp, err = s.generateEssayQuestionsOutput(ctx, sessionID, req)
+	case "cloze":
+		resp, err = s.generateClozeOutput(ctx, sessionID, req)
+	default:
+		return nil, fmt.Errorf("%w: unsupported material type: %s", ErrInvalidRequest, req.MaterialType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp.MaterialType = materialType
+	resp.Output["material_type"] = materialType
+	log.Printf("Generated %s (ID: %d)", materialType, resp.ContentID)
+	return resp, nil
+}
+
+// RunJob performs a queued generation request; it is the job queue's runner
+func (s *StudyService) RunJob(ctx context.Context, job *models.GenerationJob) (*JobResult, error) {
+	var req GenerateRequest
+	if err := json.Unmarshal(job.Request, &req); err != nil {
+		return nil, fmt.Errorf("%w: invalid request: %v", ErrJobRejected, err)
+	}
+
+	result, err := s.Generate(ctx, job.SessionID, &req)
+	if errors.Is(err, ErrInvalidRequest) {
+		return nil, fmt.Errorf("%w: %v", ErrJobRejected, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := json.Marshal(result.Output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode generation result: %w", err)
+	}
+	return &JobResult{ContentID: result.ContentID, Output: output}, nil
+}
+
+// generateSummaryOutput generates a summary
+func (s *StudyService) generateSummaryOutput(ctx context.Context, sessionID string, req *GenerateRequest) (*GenerateResponse, error) {
+	result, err := s.GenerateSummary(ctx, &GenerateSummaryRequest{
+		SessionID:     sessionID,
+		DocumentID:    req.DocumentID,
+		PageStart:     req.PageStart,
+		PageEnd:       req.PageEnd,
+		AcademicLevel: req.AcademicLevel,
+		Subject:       req.Subject,
+		TargetLength:  req.TargetLength,
+		KeepSections:  req.KeepSections,
+		Regenerate:    req.Regenerate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Cached {
+		log.Printf("Summary served from cache (ID: %d)", result.ContentID)
+	}
+
+	output := map[string]interface{}{
+		"content_id":      result.ContentID,
+		"summary":         result.Summary,
+		"extractive":      result.Extractive,
+		"subject":         result.Subject,
+		"provider":        result.Provider,
+		"model_used":      result.ModelUsed,
+		"generation_time": result.GenerationTime,
+		"cached":          result.Cached,
+	}
+	if len(result.Sections) > 0 {
+		output["sections"] = result.Sections
+	}
+	if result.CachedAt != nil {
+		output["cached_at"] = result.CachedAt
+	}
+	return &GenerateResponse{ContentID: result.ContentID, Output: output}, nil
+}
+
+// generateQuizOutput generates a quiz
+func (s *StudyService) generateQuizOutput(ctx context.Context, sessionID string, req *GenerateRequest) (*GenerateResponse, error) {
+	result, err := s.GenerateQuiz(ctx, &GenerateQuizRequest{
+		SessionID:     sessionID,
+		DocumentID:    req.DocumentID,
+		PageStart:     req.PageStart,
+		PageEnd:       req.PageEnd,
+		AcademicLevel: req.AcademicLevel,
+		Subject:       req.Subject,
+		QuestionCount: req.QuestionCount,
+		QuestionTypes: req.QuestionTypes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GenerateResponse{ContentID: result.ContentID, Output: map[string]interface{}{
+		"content_id":      result.ContentID,
+		"questions":       result.Questions,
+		"subject":         result.Subject,
+		"provider":        result.Provider,
+		"model_used":      result.ModelUsed,
+		"generation_time": result.GenerationTime,
+	}}, nil
+}
+
+// generateFlashcardsOutput generates a flashcard deck
+func (s *StudyService) generateFlashcardsOutput(ctx context.Context, sessionID string, req *GenerateRequest) (*GenerateResponse, error) {
+	result, err := s.GenerateFlashcards(ctx, &GenerateFlashcardsRequest{
+		SessionID:     sessionID,
+		DocumentID:    req.DocumentID,
+		PageStart:     req.PageStart,
+		PageEnd:       req.PageEnd,
+		AcademicLevel: req.AcademicLevel,
+		Subject:       req.Subject,
+		CardCount:     req.CardCount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GenerateResponse{ContentID: result.ContentID, Output: map[string]interface{}{
+		"content_id":      result.ContentID,
+		"cards":           result.Cards,
+		"subject":         result.Subject,
+		"provider":        result.Provider,
+		"model_used":      result.ModelUsed,
+		"generation_time": result.GenerationTime,
+	}}, nil
+}
+
+// generateNotesOutput generates study notes
+func (s *StudyService) generateNotesOutput(ctx context.Context, sessionID string, req *GenerateRequest) (*GenerateResponse, error) {
+	result, err := s.GenerateNotes(ctx, &GenerateNotesRequest{
+		SessionID:     sessionID,
+		DocumentID:    req.DocumentID,
+		PageStart:     req.PageStart,
+		PageEnd:       req.PageEnd,
+		AcademicLevel: req.AcademicLevel,
+		Subject:       req.Subject,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GenerateResponse{ContentID: result.ContentID, Output: map[string]interface{}{
+		"content_id":      result.ContentID,
+		"sections":        result.Sections,
+		"markdown":        result.Markdown,
+		"subject":         result.Subject,
+		"provider":        result.Provider,
+		"model_used":      result.ModelUsed,
+		"generation_time": result.GenerationTime,
+	}}, nil
+}
+
+// generateTimelineOutput generates a timeline
+func (s *StudyService) generateTimelineOutput(ctx context.Context, sessionID string, req *GenerateRequest) (*GenerateResponse, error) {
+	result, err := s.GenerateTimeline(ctx, &GenerateTimelineRequest{
+		SessionID:     sessionID,
+		DocumentID:    req.DocumentID,
+		PageStart:     req.PageStart,
+		PageEnd:       req.PageEnd,
+		AcademicLevel: req.AcademicLevel,
+		Subject:       req.Subject,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GenerateResponse{ContentID: result.ContentID, Output: map[string]interface{}{
+		"content_id":      result.ContentID,
+		"events":          result.Events,
+		"subject":         result.Subject,
+		"provider":        result.Provider,
+		"model_used":      result.ModelUsed,
+		"generation_time": result.GenerationTime,
+	}}, nil
+}
+
+// generateConceptMapOutput generates a concept map
+func (s *StudyService) generateConceptMapOutput(ctx context.Context, sessionID string, req *GenerateRequest) (*GenerateResponse, error) {
+	result, err := s.GenerateConceptMap(ctx, &GenerateConceptMapRequest{
+		SessionID:     sessionID,
+		DocumentID:    req.DocumentID,
+		PageStart:     req.PageStart,
+		PageEnd:       req.PageEnd,
+		AcademicLevel: req.AcademicLevel,
+		Subject:       req.Subject,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GenerateResponse{ContentID: result.ContentID, Output: map[string]interface{}{
+		"content_id":      result.ContentID,
+		"nodes":           result.Map.Nodes,
+		"edges":           result.Map.Edges,
+		"subject":         result.Subject,
+		"provider":        result.Provider,
+		"model_used":      result.ModelUsed,
+		"generation_time": result.GenerationTime,
+	}}, nil
+}
+
+// generateEssayQuestionsOutput generates essay questions with rubrics
+func (s *StudyService) generateEssayQuestionsOutput(ctx context.Context, sessionID string, req *GenerateRequest) (*GenerateResponse, error) {
+	result, err := s.GenerateEssayQuestions(ctx, &GenerateEssayQuestionsRequest{
+		SessionID:     sessionID,
+		DocumentID:    req.DocumentID,
+		PageStart:     req.PageStart,
+		PageEnd:       req.PageEnd,
+		AcademicLevel: req.AcademicLevel,
+		Subject:       req.Subject,
+		QuestionCount: req.QuestionCount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GenerateResponse{ContentID: result.ContentID, Output: map[string]interface{}{
+		"content_id":      result.ContentID,
+		"questions":       result.Questions,
+		"subject":         result.Subject,
+		"provider":        result.Provider,
+		"model_used":      result.ModelUsed,
+		"generation_time": result.GenerationTime,
+	}}, nil
+}
+
+// generateClozeOutput generates a deck of cloze deletion cards
+func (s *StudyService) generateClozeOutput(ctx context.Context, sessionID string, req *GenerateRequest) (*GenerateResponse, error) {
+	result, err := s.GenerateCloze(ctx, &GenerateClozeRequest{
+		SessionID:     sessionID,
+		DocumentID:    req.DocumentID,
+		PageStart:     req.PageStart,
+		PageEnd:       req.PageEnd,
+		AcademicLevel: req.AcademicLevel,
+		CardCount:     req.CardCount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GenerateResponse{ContentID: result.ContentID, Output: map[string]interface{}{
+		"content_id":      result.ContentID,
+		"cards":           result.Cards,
+		"card_count":      len(result.Cards),
+		"model_used":      result.ModelUsed,
+		"generation_time": result.GenerationTime,
+	}}, nil
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"studyforge/internal/models"
+	"studyforge/internal/repository"
+	"studyforge/pkg/ai"
+	"studyforge/pkg/chunker"
+	"studyforge/pkg/extractive"
+	"studyforge/pkg/prompts"
+)
+
+// GlossaryResponse contains a document's glossary
+type GlossaryResponse struct {
+	DocumentID     int                    `json:"document_id"`
+	Terms          []*models.GlossaryTerm `json:"terms"`
+	NewTerms       int                    `json:"new_terms"`       // terms added by this run
+	PagesProcessed int                    `json:"pages_processed"` // pages scanned by this run
+	GenerationTime int                    `json:"generation_time"`
+	ModelUsed      string                 `json:"model_used,omitempty"`
+}
+
+// BuildGlossary updates a document's glossary from the pages in
+// extracted_content, searching only pages not scanned by an earlier run for
+// new terms. With extractAll, the whole document is extracted first, so the
+// glossary covers every page. Page occurrences are kept complete: new pages
+// are indexed for every term, and new terms are indexed across every page.
+func (s *StudyService) BuildGlossary(ctx context.Context, sessionID string, documentID int, extractAll bool) (*GlossaryResponse, error) {
+	startTime := time.Now()
+
+	doc, err := s.docRepo.GetByID(ctx, documentID)
+	if errors.Is(err, repository.ErrDocumentNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if doc.SessionID != sessionID {
+		return nil, fmt.Errorf("%w: unauthorized access to document", ErrInvalidRequest)
+	}
+
+	if extractAll {
+		if _, _, err := s.pdfService.ExtractText(ctx, doc.ID, doc.FilePath, 1, doc.PageCount); err != nil {
+			return nil, fmt.Errorf("failed to extract text: %w", err)
+		}
+	}
+
+	pages, err := s.cachedPages(ctx, doc.ID)
+	if err != nil {
+		return nil, err
+	}
+	processed, err := s.glossaryRepo.GetProcessedPages(ctx, doc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var newPages []int
+	for page := range pages {
+		if !processed[page] {
+			newPages = append(newPages, page)
+		}
+	}
+	sort.Ints(newPages)
+
+	response := &GlossaryResponse{DocumentID: doc.ID, PagesProcessed: len(newPages)}
+	if len(newPages) > 0 {
+		log.Printf("Updating glossary for document %d: %d new pages", doc.ID, len(newPages))
+		if response.NewTerms, response.ModelUsed, err = s.updateGlossary(ctx, doc.ID, pages, newPages); err != nil {
+			return nil, err
+		}
+	}
+
+	response.Terms, err = s.glossaryRepo.GetTerms(ctx, doc.ID)
+	if err != nil {
+		return nil, err
+	}
+	response.GenerationTime = int(time.Since(startTime).Milliseconds())
+	return response, nil
+}
+
+// GetGlossary returns the stored glossary of a document
+func (s *StudyService) GetGlossary(ctx context.Context, sessionID string, documentID int) ([]*models.GlossaryTerm, error) {
+	doc, err := s.docRepo.GetByID(ctx, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+	if doc.SessionID != sessionID {
+		return nil, fmt.Errorf("unauthorized access to document")
+	}
+	return s.glossaryRepo.GetTerms(ctx, doc.ID)
+}
+
+// cachedPages returns the text of every page of a document found in
+// extracted_content, keyed by page number
+func (s *StudyService) cachedPages(ctx context.Context, documentID int) (map[int]string, error) {
+	extracted, err := s.contentRepo.GetExtractedByDocument(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[int]string)
+	for _, content := range extracted {
+		for _, page := range chunker.ParsePages(content.Content) {
+			if page.Number > 0 {
+				pages[page.Number] = page.Text
+			}
+		}
+	}
+	return pages, nil
+}
+
+// updateGlossary finds terms on newPages, merges them into the stored
+// glossary and records their page occurrences. It returns the number of
+// terms added and the model used.
+func (s *StudyService) updateGlossary(ctx context.Context, documentID int, pages map[int]string, newPages []int) (int, string, error) {
+	var b strings.Builder
+	for _, page := range newPages {
+		fmt.Fprintf(&b, "--- Page %d ---\n%s\n\n", page, strings.TrimSpace(pages[page]))
+	}
+	text := b.String()
+
+	var found []*models.GlossaryTerm
+	modelInfo, err := s.generateWith(ctx, "glossary", func(p ai.Provider) error {
+		if p.ModelInfo().Extractive {
+			found = extractiveGlossary(text)
+			return ctx.Err()
+		}
+		var err error
+		found, err = s.modelGlossary(ctx, p, text)
+		return err
+	})
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to generate glossary: %w", err)
+	}
+	model := modelLabel(modelInfo, modelInfo.Generator())
+
+	existing, err := s.glossaryRepo.GetTerms(ctx, documentID)
+	if err != nil {
+		return 0, "", err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, term := range existing {
+		known[repository.TermKey(term.Term)] = true
+	}
+
+	// Index new pages for existing terms
+	terms := existing
+	for _, term := range existing {
+		term.Pages = findTermPages(term.Term, pages, newPages)
+	}
+
+	// Index every page for new terms
+	allPages := make([]int, 0, len(pages))
+	for page := range pages {
+		allPages = append(allPages, page)
+	}
+	sort.Ints(allPages)
+
+	added := 0
+	now := time.Now()
+	for _, term := range found {
+		key := repository.TermKey(term.Term)
+		if key == "" || known[key] {
+			continue
+		}
+		known[key] = true
+
+		term.DocumentID = documentID
+		term.AIModel = model
+		term.CreatedAt = now
+		term.Pages = findTermPages(term.Term, pages, allPages)
+		if term.DefinitionPage > 0 && !containsInt(term.Pages, term.DefinitionPage) {
+			term.Pages = append(term.Pages, term.DefinitionPage)
+		}
+		terms = append(terms, term)
+		added++
+	}
+
+	if err := s.glossaryRepo.Save(ctx, documentID, terms, newPages); err != nil {
+		return 0, "", err
+	}
+	return added, model, nil
+}
+
+// modelGlossary asks the provider for the defined terms in each chunk
+func (s *StudyService) modelGlossary(ctx context.Context, p ai.Provider, text string) ([]*models.GlossaryTerm, error) {
+	chunks := s.promptChunks(p, text)
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+
+	prompts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		prompt, err := s.buildGlossaryPrompt(chunk.Text)
+		if err != nil {
+			return nil, err
+		}
+		prompts[i] = prompt
+	}
+
+	var terms []*models.GlossaryTerm
+	err := generateJSON(ctx, p, prompts, func(i int, raw string) error {
+		var out struct {
+			Terms []struct {
+				Term       string `json:"term"`
+				Definition string `json:"definition"`
+				Page       int    `json:"page"`
+			} `json:"terms"`
+		}
+		if err := decodeJSONOutput(raw, &out); err != nil {
+			return err
+		}
+
+		chunk := chunks[i]
+		for _, t := range out.Terms {
+			term := strings.TrimSpace(t.Term)
+			definition := strings.TrimSpace(t.Definition)
+			if term == "" || definition == "" {
+				continue
+			}
+			terms = append(terms, &models.GlossaryTerm{
+				Term:           term,
+				Definition:     definition,
+				DefinitionPage: clampPage(t.Page, chunk.PageStart, chunk.PageEnd, chunk.PageStart),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return terms, nil
+}
+
+// buildGlossaryPrompt asks for the defined terms of a chunk as JSON
+func (s *StudyService) buildGlossaryPrompt(text string) (string, error) {
+	return s.renderPrompt("glossary", prompts.GeneralSubject, "", prompts.Data{Text: text})
+}
+
+// extractiveGlossary collects terms from defining sentences ("X is a ...")
+// and recurring key terms, which are defined by their first mention
+func extractiveGlossary(text string) []*models.GlossaryTerm {
+	sentences := extractive.Sentences(text)
+
+	var terms []*models.GlossaryTerm
+	for _, d := range extractive.Definitions(sentences) {
+		terms = append(terms, &models.GlossaryTerm{
+			Term:           d.Term,
+			Definition:     d.Text,
+			DefinitionPage: d.Page,
+		})
+	}
+
+	for _, t := range extractive.KeyTerms(sentences, 0) {
+		if t.Kind != extractive.TermName || t.Count < 2 {
+			continue
+		}
+		first := sentences[t.Sentences[0]]
+		terms = append(terms, &models.GlossaryTerm{
+			Term:           t.Text,
+			Definition:     first.Text,
+			DefinitionPage: first.Page,
+		})
+	}
+
+	return terms
+}
+
+// findTermPages returns the pages, of those given, whose text mentions term
+// as a whole word, ignoring case
+func findTermPages(term string, pages map[int]string, candidates []int) []int {
+	re, err := regexp.Compile(`(?i)\b` + regexp.QuoteMeta(term) + `\b`)
+	if err != nil {
+		return nil
+	}
+
+	var found []int
+	for _, page := range candidates {
+		if re.MatchString(pages[page]) {
+			found = append(found, page)
+		}
+	}
+	return found
+}
+
+// containsInt reports whether list includes value
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"studyforge/internal/models"
+	"studyforge/internal/repository"
+	"studyforge/pkg/ai"
+)
+
+// jobPollInterval is how often idle workers look for jobs whose retry
+// delay has passed; new jobs wake a worker immediately
+const jobPollInterval = 2 * time.Second
+
+// jobRetryDelay is the wait before a failed job's next attempt, multiplied
+// by the number of attempts so far
+const jobRetryDelay = 30 * time.Second
+
+// maxSessionJobs is how many recent jobs ListJobs returns
+const maxSessionJobs = 50
+
+// ErrJobRejected marks job failures that retrying cannot fix, such as an
+// invalid request
+var ErrJobRejected = errors.New("job rejected")
+
+// JobResult is the outcome of a successful job
+type JobResult struct {
+	ContentID int
+	Output    []byte // JSON response, as /api/study/generate would return it
+}
+
+// JobRunner performs a job's generation request
+type JobRunner func(ctx context.Context, job *models.GenerationJob) (*JobResult, error)
+
+// JobQueue runs generation jobs stored in the database on a pool of
+// workers. Jobs outlive the server: those interrupted by a restart are
+// queued again, or failed once they have used up their attempts.
+type JobQueue struct {
+	repo        *repository.JobRepository
+	workers     int
+	maxAttempts int
+	wake        chan struct{}
+
+	mu      sync.Mutex
+	running map[int]context.CancelFunc // cancels each running job, by ID
+	stop    context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewJobQueue creates a job queue with the given number of workers, each
+// job running at most maxAttempts times
+func NewJobQueue(repo *repository.JobRepository, workers, maxAttempts int) *JobQueue {
+	if workers <= 0 {
+		workers = 1
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	return &JobQueue{
+		repo:        repo,
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		wake:        make(chan struct{}, 1),
+		running:     make(map[int]context.CancelFunc),
+	}
+}
+
+// Start recovers jobs interrupted by the last shutdown and starts the
+// workers, which run jobs with run until Stop is called
+func (q *JobQueue) Start(ctx context.Context, run JobRunner) error {
+	requeued, failed, err := q.repo.RecoverInterrupted(ctx, q.maxAttempts)
+	if err != nil {
+		return err
+	}
+	if requeued > 0 || failed > 0 {
+		log.Printf("Recovered interrupted jobs: %d requeued, %d failed", requeued, failed)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	q.stop = cancel
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx, run)
+	}
+	log.Printf("Started %d job workers", q.workers)
+	return nil
+}
+
+// Stop cancels running jobs and waits for the workers to exit. Cancelled
+// jobs stay marked as running, so the next Start queues them again.
+func (q *JobQueue) Stop() {
+	if q.stop != nil {
+		q.stop()
+	}
+	q.wg.Wait()
+}
+
+// Enqueue stores a generation request as a new job
+func (q *JobQueue) Enqueue(ctx context.Context, sessionID, materialType string, request []byte) (*models.GenerationJob, error) {
+	job := &models.GenerationJob{
+		SessionID:    sessionID,
+		MaterialType: materialType,
+		Request:      request,
+	}
+	if err := q.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// Wake an idle worker; if none is idle, a busy one finds the job next
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// GetJob retrieves a job, verifying the session owns it
+func (q *JobQueue) GetJob(ctx context.Context, id int, sessionID string) (*models.GenerationJob, error) {
+	job, err := q.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.SessionID != sessionID {
+		return nil, fmt.Errorf("unauthorized access to job")
+	}
+	return job, nil
+}
+
+// ListJobs returns a session's most recent jobs, newest first
+func (q *JobQueue) ListJobs(ctx context.Context, sessionID string) ([]*models.GenerationJob, error) {
+	return q.repo.GetBySession(ctx, sessionID, maxSessionJobs)
+}
+
+// CancelJob cancels a queued or running job. It reports false when the job
+// had already finished.
+func (q *JobQueue) CancelJob(ctx context.Context, id int, sessionID string) (*models.GenerationJob, bool, error) {
+	if _, err := q.GetJob(ctx, id, sessionID); err != nil {
+		return nil, false, err
+	}
+
+	cancelled, err := q.repo.Cancel(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if cancelled {
+		q.mu.Lock()
+		if cancel, ok := q.running[id]; ok {
+			cancel()
+		}
+		q.mu.Unlock()
+		log.Printf("Cancelled job %d", id)
+	}
+
+	job, err := q.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	return job, cancelled, nil
+}
+
+// work claims and runs jobs until ctx is cancelled
+func (q *JobQueue) work(ctx context.Context, run JobRunner) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := q.repo.ClaimNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to claim job: %v", err)
+		}
+		if job != nil {
+			q.runJob(ctx, job, run)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runJob runs one claimed job and records its outcome
+func (q *JobQueue) runJob(ctx context.Context, job *models.GenerationJob, run JobRunner) {
+	log.Printf("Running job %d (%s, attempt %d/%d)", job.ID, job.MaterialType, job.Attempts, q.maxAttempts)
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	q.mu.Lock()
+	q.running[job.ID] = cancel
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		delete(q.running, job.ID)
+		q.mu.Unlock()
+	}()
+
+	// Record progress events. Nobody reads a job's output as it is written,
+	// so providers are not asked to stream. Chunks finish concurrently and
+	// may report out of order, so progress is only ever raised.
+	var progressMu sync.Mutex
+	progress := 0
+	jobCtx = ai.WithListener(jobCtx, func(e ai.Event) {
+		if e.Type != ai.EventProgress {
+			return
+		}
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		p := jobProgress(e)
+		if p < progress {
+			return
+		}
+		progress = p
+		if err := q.repo.UpdateProgress(ctx, job.ID, p, e.Message); err != nil {
+			log.Printf("Failed to record progress of job %d: %v", job.ID, err)
+		}
+	})
+
+	result, err := run(jobCtx, job)
+
+	// A job stopped by shutdown stays running, to be requeued on restart; one
+	// stopped by CancelJob was already marked cancelled
+	if jobCtx.Err() != nil {
+		return
+	}
+
+	switch {
+	case err == nil:
+		err = q.repo.Succeed(ctx, job.ID, result.ContentID, result.Output)
+		log.Printf("Job %d succeeded (content ID: %d)", job.ID, result.ContentID)
+	case errors.Is(err, ErrJobRejected) || job.Attempts >= q.maxAttempts:
+		log.Printf("Job %d failed: %v", job.ID, err)
+		err = q.repo.Fail(ctx, job.ID, err.Error())
+	default:
+		delay := time.Duration(job.Attempts) * jobRetryDelay
+		log.Printf("Job %d failed, retrying in %s: %v", job.ID, delay, err)
+		err = q.repo.Retry(ctx, job.ID, err.Error(), time.Now().Add(delay))
+	}
+	if err != nil {
+		log.Printf("Failed to record outcome of job %d: %v", job.ID, err)
+	}
+}
+
+// jobProgress estimates how far along a job is from a progress event
+func jobProgress(e ai.Event) int {
+	switch e.Stage {
+	case ai.StageExtraction, ai.StageFallback:
+		return 10
+	case ai.StageChunk:
+		if e.Total > 0 {
+			return 10 + 80*e.Done/e.Total
+		}
+	case ai.StageReduce:
+		return 95
+	}
+	return 10
+}
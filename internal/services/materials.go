@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"studyforge/internal/models"
+	"studyforge/internal/repository"
+	"studyforge/pkg/ai"
+	"studyforge/pkg/chunker"
+	"studyforge/pkg/prompts"
+)
+
+// CheckPages verifies the session owns the document and the page range is
+// valid, without extracting any text
+func (s *StudyService) CheckPages(ctx context.Context, sessionID string, documentID, pageStart, pageEnd int) error {
+	_, err := s.checkPages(ctx, sessionID, documentID, pageStart, pageEnd)
+	return err
+}
+
+// checkPages is CheckPages, returning the document
+func (s *StudyService) checkPages(ctx context.Context, sessionID string, documentID, pageStart, pageEnd int) (*models.Document, error) {
+	// Get document
+	doc, err := s.docRepo.GetByID(ctx, documentID)
+	if errors.Is(err, repository.ErrDocumentNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify session matches
+	if doc.SessionID != sessionID {
+		return nil, fmt.Errorf("%w: unauthorized access to document", ErrInvalidRequest)
+	}
+
+	// Validate page range
+	if err := s.pdfService.ValidatePageRange(doc.FilePath, pageStart, pageEnd); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	return doc, nil
+}
+
+// loadPages verifies the session owns the document and returns the
+// extracted text of the page range, with "--- Page N ---" markers
+func (s *StudyService) loadPages(ctx context.Context, sessionID string, documentID, pageStart, pageEnd int) (string, error) {
+	doc, err := s.checkPages(ctx, sessionID, documentID, pageStart, pageEnd)
+	if err != nil {
+		return "", err
+	}
+
+	// Extract text from PDF
+	text, _, err := s.pdfService.ExtractText(ctx, documentID, doc.FilePath, pageStart, pageEnd)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract text: %w", err)
+	}
+	ai.Report(ctx, ai.Event{
+		Type:    ai.EventProgress,
+		Stage:   ai.StageExtraction,
+		Message: fmt.Sprintf("Extracted pages %d-%d", pageStart, pageEnd),
+	})
+	if !hasText(text) {
+		return "", errNoText
+	}
+
+	return text, nil
+}
+
+// hasText reports whether extracted text holds anything besides page markers
+func hasText(text string) bool {
+	for _, page := range chunker.ParsePages(text) {
+		if strings.TrimSpace(page.Text) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// saveContent marshals output into content and stores it
+func (s *StudyService) saveContent(ctx context.Context, content *models.GeneratedContent, output interface{}) error {
+	if err := setOutput(content, output); err != nil {
+		return err
+	}
+
+	if err := s.contentRepo.CreateGenerated(ctx, content); err != nil {
+		return fmt.Errorf("failed to save content: %w", err)
+	}
+	return nil
+}
+
+// setOutput marshals output into content and stamps its creation time
+func setOutput(content *models.GeneratedContent, output interface{}) error {
+	outputJSON, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+
+	content.OutputContent = string(outputJSON)
+	content.CreatedAt = time.Now()
+	return nil
+}
+
+// promptChunks splits source text into chunks that fit p's input limit,
+// keeping page markers so the model can cite pages
+func (s *StudyService) promptChunks(p ai.Provider, text string) []chunker.Chunk {
+	maxTokens := p.ModelInfo().MaxInputTokens
+	if maxTokens <= 0 {
+		maxTokens = 750
+	}
+
+	return chunker.Split(text, chunker.Options{
+		MaxTokens:     maxTokens,
+		OverlapTokens: s.cfg.ChunkOverlapTokens,
+		Tokenizer:     s.tokenizer,
+		PageMarkers:   true,
+	})
+}
+
+// distribute spreads total items across parts as evenly as possible, with
+// item i assigned to part i*parts/total, and returns the count per part
+func distribute(total, parts int) []int {
+	counts := make([]int, parts)
+	for i := 0; i < total; i++ {
+		counts[i*parts/total]++
+	}
+	return counts
+}
+
+// generateJSON runs each prompt through the provider and passes the
+// responses to decode in prompt order
+func generateJSON(ctx context.Context, p ai.Provider, prompts []string, decode func(i int, raw string) error) error {
+	outputs, err := ai.GenerateAll(ctx, p, prompts)
+	if err != nil {
+		return err
+	}
+
+	for i, raw := range outputs {
+		if err := decode(i, raw); err != nil {
+			return fmt.Errorf("part %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// decodeJSONOutput decodes the first JSON object or array in model output.
+// Models often wrap JSON in prose or Markdown code fences.
+func decodeJSONOutput(raw string, v interface{}) error {
+	start := strings.IndexAny(raw, "{[")
+	if start < 0 {
+		return fmt.Errorf("model output contains no JSON")
+	}
+
+	dec := json.NewDecoder(strings.NewReader(raw[start:]))
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("model output is not valid JSON: %w", err)
+	}
+	return nil
+}
+
+// Subjects returns the subject keys prompts can be written for
+func (s *StudyService) Subjects() []string {
+	return s.prompts.Subjects()
+}
+
+// resolveSubject returns the subject key to write prompts for, detecting it
+// from text when the request asks for automatic detection
+func (s *StudyService) resolveSubject(subject, text string) string {
+	switch subject {
+	case "":
+		return prompts.GeneralSubject
+	case prompts.AutoSubject:
+		detected := s.prompts.DetectSubject(text)
+		log.Printf("Detected subject: %s", detected)
+		return detected
+	}
+	return subject
+}
+
+// renderPrompt fills in the library prompt for a material type, adding the
+// academic level and its audience to data
+func (s *StudyService) renderPrompt(material, subject, academicLevel string, data prompts.Data) (string, error) {
+	data.Level = academicLevel
+	data.Audience = audience(academicLevel)
+	prompt, err := s.prompts.Render(material, subject, data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errPromptRender, err)
+	}
+	return prompt, nil
+}
+
+// audience describes an academic level for use in prompts
+func audience(academicLevel string) string {
+	switch academicLevel {
+	case "high_school":
+		return "high school students"
+	case "graduate":
+		return "graduate students"
+	default:
+		return "undergraduate students"
+	}
+}
+
+// pageRange formats a page range for storage
+func pageRange(start, end int) string {
+	return fmt.Sprintf("%d-%d", start, end)
+}
+
+// clampPage returns page when it lies within the range, otherwise fallback
+func clampPage(page, start, end, fallback int) int {
+	if page >= start && page <= end {
+		return page
+	}
+	return fallback
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"studyforge/internal/models"
+	"studyforge/pkg/ai"
+	"studyforge/pkg/extractive"
+	"studyforge/pkg/prompts"
+)
+
+// maxNoteDepth is the deepest bullet nesting kept from model output
+const maxNoteDepth = 3
+
+// GenerateNotesRequest contains parameters for notes generation
+type GenerateNotesRequest struct {
+	SessionID     string
+	DocumentID    int
+	PageStart     int
+	PageEnd       int
+	AcademicLevel string
+	Subject       string
+}
+
+// GenerateNotesResponse contains the generated outline
+type GenerateNotesResponse struct {
+	ContentID      int                  `json:"content_id"`
+	Sections       []models.NoteSection `json:"sections"`
+	Markdown       string               `json:"markdown"`
+	Subject        string               `json:"subject"`
+	Provider       string               `json:"provider"`
+	GenerationTime int                  `json:"generation_time"`
+	ModelUsed      string               `json:"model_used"`
+}
+
+// GenerateNotes turns specified pages into a hierarchical outline, returned
+// both as structured sections and rendered Markdown
+func (s *StudyService) GenerateNotes(ctx context.Context, req *GenerateNotesRequest) (*GenerateNotesResponse, error) {
+	startTime := time.Now()
+
+	text, err := s.loadPages(ctx, req.SessionID, req.DocumentID, req.PageStart, req.PageEnd)
+	if err != nil {
+		return nil, err
+	}
+	subject := s.resolveSubject(req.Subject, text)
+
+	var sections []models.NoteSection
+	modelInfo, err := s.generateWith(ctx, "notes", func(p ai.Provider) error {
+		if p.ModelInfo().Extractive {
+			sections = extractiveNotes(text, req.AcademicLevel)
+			return ctx.Err()
+		}
+		var err error
+		sections, err = s.modelNotes(ctx, p, text, subject, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate notes: %w", err)
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("failed to generate notes: no content in the selected pages")
+	}
+
+	markdown := renderNotesMarkdown(sections)
+	generationTime := int(time.Since(startTime).Milliseconds())
+
+	// Create output content structure
+	outputData := map[string]interface{}{
+		"sections":       sections,
+		"markdown":       markdown,
+		"pages":          pageRange(req.PageStart, req.PageEnd),
+		"academic_level": req.AcademicLevel,
+		"subject":        subject,
+	}
+	if !modelInfo.Extractive {
+		outputData["prompt_version"] = s.prompts.Version()
+	}
+
+	// Save to database
+	generatedContent := &models.GeneratedContent{
+		SessionID:      req.SessionID,
+		DocumentID:     req.DocumentID,
+		ContentType:    "notes",
+		AcademicLevel:  req.AcademicLevel,
+		InputPages:     pageRange(req.PageStart, req.PageEnd),
+		AIModel:        modelLabel(modelInfo, modelInfo.Generator()),
+		GenerationTime: generationTime,
+	}
+	if err := s.saveContent(ctx, generatedContent, outputData); err != nil {
+		return nil, err
+	}
+
+	return &GenerateNotesResponse{
+		ContentID:      generatedContent.ID,
+		Sections:       sections,
+		Markdown:       markdown,
+		Subject:        subject,
+		Provider:       modelInfo.Provider,
+		GenerationTime: generationTime,
+		ModelUsed:      modelLabel(modelInfo, modelInfo.Generator()),
+	}, nil
+}
+
+// modelNotes asks the provider to outline each chunk of the source. Section
+// page references are checked against the page markers in the chunk.
+func (s *StudyService) modelNotes(ctx context.Context, p ai.Provider, text, subject string, req *GenerateNotesRequest) ([]models.NoteSection, error) {
+	chunks := s.promptChunks(p, text)
+	if len(chunks) == 0 {
+		return nil, errNoText
+	}
+
+	prompts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		prompt, err := s.buildNotesPrompt(chunk.Text, subject, req.AcademicLevel)
+		if err != nil {
+			return nil, err
+		}
+		prompts[i] = prompt
+	}
+
+	var sections []models.NoteSection
+	err := generateJSON(ctx, p, prompts, func(i int, raw string) error {
+		var out struct {
+			Sections []models.NoteSection `json:"sections"`
+		}
+		if err := decodeJSONOutput(raw, &out); err != nil {
+			return err
+		}
+
+		chunk := chunks[i]
+		for _, section := range out.Sections {
+			section.Heading = strings.TrimSpace(section.Heading)
+			section.Bullets = cleanBullets(section.Bullets, 1)
+			if section.Heading == "" || len(section.Bullets) == 0 {
+				continue
+			}
+
+			section.PageStart = clampPage(section.PageStart, chunk.PageStart, chunk.PageEnd, chunk.PageStart)
+			section.PageEnd = clampPage(section.PageEnd, section.PageStart, chunk.PageEnd, section.PageStart)
+			sections = append(sections, section)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
+
+// buildNotesPrompt asks for an outline of a chunk as JSON
+func (s *StudyService) buildNotesPrompt(text, subject, academicLevel string) (string, error) {
+	return s.renderPrompt("notes", subject, academicLevel, prompts.Data{Text: text, MaxDepth: maxNoteDepth})
+}
+
+// cleanBullets drops empty bullets and nesting deeper than maxNoteDepth
+func cleanBullets(bullets []models.NoteBullet, depth int) []models.NoteBullet {
+	var cleaned []models.NoteBullet
+	for _, b := range bullets {
+		b.Text = strings.TrimSpace(b.Text)
+		if b.Text == "" {
+			continue
+		}
+
+		var terms []string
+		for _, term := range b.KeyTerms {
+			if term = strings.TrimSpace(term); term != "" {
+				terms = append(terms, term)
+			}
+		}
+		b.KeyTerms = terms
+
+		if depth < maxNoteDepth {
+			b.Children = cleanBullets(b.Children, depth+1)
+		} else {
+			b.Children = nil
+		}
+		cleaned = append(cleaned, b)
+	}
+	return cleaned
+}
+
+// extractiveNotes outlines each page from its highest-ranked sentences, with
+// nearby sentences that share a key term as sub-points
+func extractiveNotes(text, academicLevel string) []models.NoteSection {
+	perPage := 3
+	switch academicLevel {
+	case "high_school":
+		perPage = 2
+	case "graduate":
+		perPage = 4
+	}
+
+	sentences := extractive.Sentences(text)
+	terms := extractive.KeyTerms(sentences, 0)
+
+	// Group sentences by page, keeping document order
+	var pages []int
+	byPage := make(map[int][]extractive.Sentence)
+	for _, s := range sentences {
+		if _, ok := byPage[s.Page]; !ok {
+			pages = append(pages, s.Page)
+		}
+		byPage[s.Page] = append(byPage[s.Page], s)
+	}
+
+	var sections []models.NoteSection
+	usedHeadings := make(map[string]bool)
+	for _, page := range pages {
+		pageSentences := byPage[page]
+		top := extractive.TopSentences(pageSentences, perPage)
+
+		selected := make(map[int]bool)
+		for _, s := range top {
+			selected[s.Index] = true
+		}
+
+		section := models.NoteSection{
+			Heading:   sectionHeading(pageSentences, terms, page, usedHeadings),
+			PageStart: page,
+			PageEnd:   page,
+		}
+
+		for _, s := range top {
+			bullet := models.NoteBullet{
+				Text:     s.Text,
+				KeyTerms: termsIn(s.Text, terms),
+			}
+
+			// Following sentences on the page that mention the same terms
+			for _, next := range pageSentences {
+				if len(bullet.Children) == 2 {
+					break
+				}
+				if next.Index <= s.Index || selected[next.Index] || !sharesTerm(next.Text, bullet.KeyTerms) {
+					continue
+				}
+				selected[next.Index] = true
+				bullet.Children = append(bullet.Children, models.NoteBullet{
+					Text:     next.Text,
+					KeyTerms: termsIn(next.Text, terms),
+				})
+			}
+
+			section.Bullets = append(section.Bullets, bullet)
+		}
+
+		sections = append(sections, section)
+	}
+
+	return sections
+}
+
+// sectionHeading names a page section after its most frequent key term not
+// already used as a heading
+func sectionHeading(sentences []extractive.Sentence, terms []extractive.Term, page int, used map[string]bool) string {
+	heading := "Overview"
+	if page > 0 {
+		heading = fmt.Sprintf("Page %d", page)
+	}
+
+	for _, t := range terms {
+		if t.Kind != extractive.TermName || used[t.Text] {
+			continue
+		}
+		for _, s := range sentences {
+			if strings.Contains(s.Text, t.Text) {
+				used[t.Text] = true
+				return t.Text
+			}
+		}
+	}
+	return heading
+}
+
+// termsIn returns the key terms that appear in text, most frequent first
+func termsIn(text string, terms []extractive.Term) []string {
+	var found []string
+	for _, t := range terms {
+		if t.Count > 1 && strings.Contains(text, t.Text) {
+			found = append(found, t.Text)
+		}
+		if len(found) == 3 {
+			break
+		}
+	}
+	return found
+}
+
+// sharesTerm reports whether text mentions any of terms
+func sharesTerm(text string, terms []string) bool {
+	for _, t := range terms {
+		if strings.Contains(text, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderNotesMarkdown renders sections as a Markdown outline with page
+// references and key terms in bold
+func renderNotesMarkdown(sections []models.NoteSection) string {
+	var b strings.Builder
+	for i, section := range sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("## ")
+		b.WriteString(section.Heading)
+		switch {
+		case section.PageStart == 0:
+		case section.PageEnd > section.PageStart:
+			fmt.Fprintf(&b, " (pp. %d-%d)", section.PageStart, section.PageEnd)
+		default:
+			fmt.Fprintf(&b, " (p. %d)", section.PageStart)
+		}
+		b.WriteString("\n\n")
+		writeBullets(&b, section.Bullets, 0)
+	}
+	return b.String()
+}
+
+// writeBullets writes bullets as a nested Markdown list
+func writeBullets(b *strings.Builder, bullets []models.NoteBullet, depth int) {
+	for _, bullet := range bullets {
+		b.WriteString(strings.Repeat("  ", depth))
+		b.WriteString("- ")
+		b.WriteString(boldTerms(bullet.Text, bullet.KeyTerms))
+		b.WriteString("\n")
+		writeBullets(b, bullet.Children, depth+1)
+	}
+}
+
+// boldTerms wraps the first occurrence of each term in Markdown bold
+func boldTerms(text string, terms []string) string {
+	for _, term := range terms {
+		idx := strings.Index(text, term)
+		if idx < 0 {
+			continue
+		}
+		// Skip terms inside an already bolded term
+		if strings.Count(text[:idx], "**")%2 == 1 {
+			continue
+		}
+		text = text[:idx] + "**" + term + "**" + text[idx+len(term):]
+	}
+	return text
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -11,7 +12,7 @@ import (
 
 // PDFService handles PDF-related business logic
 type PDFService struct {
-	extractor  *pdf.Extractor
+	extractor   *pdf.Extractor
 	contentRepo *repository.ContentRepository
 }
 
@@ -29,11 +30,11 @@ func (s *PDFService) GetPageCount(filePath string) (int, error) {
 }
 
 // ExtractText extracts text from specified page range with caching
-func (s *PDFService) ExtractText(documentID int, filePath string, startPage, endPage int) (string, int, error) {
+func (s *PDFService) ExtractText(ctx context.Context, documentID int, filePath string, startPage, endPage int) (string, int, error) {
 	startTime := time.Now()
 
 	// Check cache first
-	cached, err := s.contentRepo.GetExtracted(documentID, startPage, endPage)
+	cached, err := s.contentRepo.GetExtracted(ctx, documentID, startPage, endPage)
 	if err != nil {
 		return "", 0, fmt.Errorf("cache lookup failed: %w", err)
 	}
@@ -44,7 +45,7 @@ func (s *PDFService) ExtractText(documentID int, filePath string, startPage, end
 	}
 
 	// Extract text from PDF
-	text, err := s.extractor.ExtractText(filePath, startPage, endPage)
+	text, err := s.extractor.ExtractText(ctx, filePath, startPage, endPage)
 	if err != nil {
 		return "", 0, err
 	}
@@ -61,7 +62,7 @@ func (s *PDFService) ExtractText(documentID int, filePath string, startPage, end
 		CreatedAt:      time.Now(),
 	}
 
-	if err := s.contentRepo.CreateExtracted(extractedContent); err != nil {
+	if err := s.contentRepo.CreateExtracted(ctx, extractedContent); err != nil {
 		// Log error but don't fail the request
 		fmt.Printf("Failed to cache extracted content: %v\n", err)
 	}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"studyforge/pkg/ai"
+)
+
+var (
+	// errNoText is returned for page ranges without any extracted text
+	errNoText = fmt.Errorf("%w: no text in selected pages", ErrInvalidRequest)
+
+	// errPromptRender is wrapped by failures to render a library prompt
+	errPromptRender = errors.New("failed to render prompt")
+)
+
+// generateWith runs generate with each provider in the material type's
+// chain until one succeeds, and returns the model info of the provider
+// that produced the output. Providers whose circuit is open are skipped.
+// Errors caused by the request or the prompt library end the chain without
+// counting against the provider.
+func (s *StudyService) generateWith(ctx context.Context, material string, generate func(p ai.Provider) error) (ai.ModelInfo, error) {
+	var failures []string
+	for _, b := range s.chains.For(material) {
+		if !b.Allow() {
+			failures = append(failures, b.Name()+": circuit open")
+			continue
+		}
+
+		err := generate(b)
+		if err == nil {
+			b.Success()
+			return b.ModelInfo(), nil
+		}
+		// A cancelled request says nothing about the provider's health
+		if ctx.Err() != nil {
+			b.Release()
+			return ai.ModelInfo{}, ctx.Err()
+		}
+		// Neither would the next provider fix an invalid request or prompt
+		if errors.Is(err, ErrInvalidRequest) || errors.Is(err, errPromptRender) {
+			b.Release()
+			return ai.ModelInfo{}, err
+		}
+
+		// A provider that cannot do what was asked is skipped, not failed
+		if errors.Is(err, ai.ErrUnsupported) {
+			b.Release()
+			failures = append(failures, fmt.Sprintf("%s: %v", b.Name(), err))
+			continue
+		}
+
+		b.Failure()
+		log.Printf("Provider %s failed to generate %s, trying the next provider: %v", b.Name(), material, err)
+		ai.Report(ctx, ai.Event{
+			Type:    ai.EventProgress,
+			Stage:   ai.StageFallback,
+			Message: fmt.Sprintf("Provider %s failed, trying the next provider", b.Name()),
+		})
+		failures = append(failures, fmt.Sprintf("%s: %v", b.Name(), err))
+	}
+
+	return ai.ModelInfo{}, fmt.Errorf("no provider could generate %s (%s)", material, strings.Join(failures, "; "))
+}
+
+// modelLabel records which provider and model produced output, e.g.
+// "ollama:llama3.1"
+func modelLabel(info ai.ModelInfo, model string) string {
+	return info.Provider + ":" + model
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"studyforge/internal/models"
+	"studyforge/pkg/ai"
+	"studyforge/pkg/extractive"
+	"studyforge/pkg/prompts"
+)
+
+// Quiz size limits
+const (
+	DefaultQuestionCount = 10
+	MaxQuestionCount     = 50
+)
+
+// QuestionTypes lists the supported quiz question types in default mix order
+var QuestionTypes = []string{
+	models.QuestionMultipleChoice,
+	models.QuestionTrueFalse,
+	models.QuestionShortAnswer,
+}
+
+// GenerateQuizRequest contains parameters for quiz generation
+type GenerateQuizRequest struct {
+	SessionID     string
+	DocumentID    int
+	PageStart     int
+	PageEnd       int
+	AcademicLevel string
+	Subject       string
+	QuestionCount int      // total questions, defaults to DefaultQuestionCount
+	QuestionTypes []string // mix of question types, spread evenly; defaults to all types
+}
+
+// GenerateQuizResponse contains the generated quiz
+type GenerateQuizResponse struct {
+	ContentID      int                   `json:"content_id"`
+	Questions      []models.QuizQuestion `json:"questions"`
+	Subject        string                `json:"subject"`
+	Provider       string                `json:"provider"`
+	GenerationTime int                   `json:"generation_time"`
+	ModelUsed      string                `json:"model_used"`
+}
+
+// GenerateQuiz generates quiz questions from specified pages
+func (s *StudyService) GenerateQuiz(ctx context.Context, req *GenerateQuizRequest) (*GenerateQuizResponse, error) {
+	startTime := time.Now()
+
+	text, err := s.loadPages(ctx, req.SessionID, req.DocumentID, req.PageStart, req.PageEnd)
+	if err != nil {
+		return nil, err
+	}
+	subject := s.resolveSubject(req.Subject, text)
+
+	count := req.QuestionCount
+	if count <= 0 {
+		count = DefaultQuestionCount
+	}
+	if count > MaxQuestionCount {
+		count = MaxQuestionCount
+	}
+	types := req.QuestionTypes
+	if len(types) == 0 {
+		types = QuestionTypes
+	}
+
+	// Assign a type to every question, cycling through the requested mix
+	plan := make([]string, count)
+	for i := range plan {
+		plan[i] = types[i%len(types)]
+	}
+
+	var questions []models.QuizQuestion
+	modelInfo, err := s.generateWith(ctx, "quiz", func(p ai.Provider) error {
+		if p.ModelInfo().Extractive {
+			questions = extractiveQuiz(text, plan)
+			return ctx.Err()
+		}
+		var err error
+		questions, err = s.modelQuiz(ctx, p, text, subject, req, plan)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate quiz: %w", err)
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("failed to generate quiz: no questions could be drawn from the selected pages")
+	}
+
+	generationTime := int(time.Since(startTime).Milliseconds())
+
+	// Create output content structure
+	outputData := map[string]interface{}{
+		"questions":      questions,
+		"pages":          pageRange(req.PageStart, req.PageEnd),
+		"academic_level": req.AcademicLevel,
+		"subject":        subject,
+		"question_count": len(questions),
+	}
+	if !modelInfo.Extractive {
+		outputData["prompt_version"] = s.prompts.Version()
+	}
+
+	// Save to database
+	generatedContent := &models.GeneratedContent{
+		SessionID:      req.SessionID,
+		DocumentID:     req.DocumentID,
+		ContentType:    "quiz",
+		AcademicLevel:  req.AcademicLevel,
+		InputPages:     pageRange(req.PageStart, req.PageEnd),
+		AIModel:        modelLabel(modelInfo, modelInfo.Generator()),
+		GenerationTime: generationTime,
+	}
+	if err := s.saveContent(ctx, generatedContent, outputData); err != nil {
+		return nil, err
+	}
+
+	return &GenerateQuizResponse{
+		ContentID:      generatedContent.ID,
+		Questions:      questions,
+		Subject:        subject,
+		Provider:       modelInfo.Provider,
+		GenerationTime: generationTime,
+		ModelUsed:      modelLabel(modelInfo, modelInfo.Generator()),
+	}, nil
+}
+
+// modelQuiz asks the provider for questions, spreading the plan across
+// chunks of the source so long ranges are covered evenly
+func (s *StudyService) modelQuiz(ctx context.Context, p ai.Provider, text, subject string, req *GenerateQuizRequest, plan []string) ([]models.QuizQuestion, error) {
+	chunks := s.promptChunks(p, text)
+	if len(chunks) == 0 {
+		return nil, errNoText
+	}
+
+	// Give each chunk a contiguous slice of the plan
+	chunkPlans := make([][]string, len(chunks))
+	next := 0
+	for c, n := range distribute(len(plan), len(chunks)) {
+		chunkPlans[c] = plan[next : next+n]
+		next += n
+	}
+
+	var prompts []string
+	var promptChunks []int
+	for c, chunkPlan := range chunkPlans {
+		if len(chunkPlan) == 0 {
+			continue
+		}
+		prompt, err := s.buildQuizPrompt(chunks[c].Text, subject, req.AcademicLevel, chunkPlan)
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, prompt)
+		promptChunks = append(promptChunks, c)
+	}
+
+	var questions []models.QuizQuestion
+	err := generateJSON(ctx, p, prompts, func(i int, raw string) error {
+		var out struct {
+			Questions []models.QuizQuestion `json:"questions"`
+		}
+		if err := decodeJSONOutput(raw, &out); err != nil {
+			return err
+		}
+
+		chunk := chunks[promptChunks[i]]
+		for _, q := range out.Questions {
+			if normalizeQuestion(&q) {
+				q.SourcePage = clampPage(q.SourcePage, req.PageStart, req.PageEnd, chunk.PageStart)
+				questions = append(questions, q)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Let the next provider try when none of the questions is usable
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("model output contains no valid questions")
+	}
+	if len(questions) > len(plan) {
+		questions = questions[:len(plan)]
+	}
+	log.Printf("Generated %d of %d requested quiz questions", len(questions), len(plan))
+	return questions, nil
+}
+
+// buildQuizPrompt asks for questions of the planned types as JSON
+func (s *StudyService) buildQuizPrompt(text, subject, academicLevel string, plan []string) (string, error) {
+	counts := make(map[string]int)
+	for _, t := range plan {
+		counts[t]++
+	}
+	var mix []string
+	for _, t := range QuestionTypes {
+		if counts[t] > 0 {
+			mix = append(mix, fmt.Sprintf("%d %s", counts[t], t))
+		}
+	}
+
+	return s.renderPrompt("quiz", subject, academicLevel, prompts.Data{
+		Text:  text,
+		Count: len(plan),
+		Mix:   strings.Join(mix, ", "),
+	})
+}
+
+// normalizeQuestion cleans up a model-written question, reporting false when
+// it is unusable
+func normalizeQuestion(q *models.QuizQuestion) bool {
+	q.Type = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(q.Type)), " ", "_")
+	q.Type = strings.ReplaceAll(q.Type, "-", "_")
+	q.Stem = strings.TrimSpace(q.Stem)
+	q.Answer = strings.TrimSpace(q.Answer)
+	q.Explanation = strings.TrimSpace(q.Explanation)
+	if q.Stem == "" || q.Answer == "" {
+		return false
+	}
+
+	switch q.Type {
+	case models.QuestionMultipleChoice, "mcq", "multiple":
+		q.Type = models.QuestionMultipleChoice
+		if len(q.Options) < 2 {
+			return false
+		}
+		// Accept an option letter ("B") as the answer
+		if len(q.Answer) == 1 {
+			if i := int(strings.ToUpper(q.Answer)[0] - 'A'); i >= 0 && i < len(q.Options) {
+				q.Answer = q.Options[i]
+			}
+		}
+		for _, option := range q.Options {
+			if strings.EqualFold(strings.TrimSpace(option), q.Answer) {
+				q.Answer = option
+				return true
+			}
+		}
+		return false
+	case models.QuestionTrueFalse, "true/false", "truefalse", "boolean":
+		q.Type = models.QuestionTrueFalse
+		switch strings.ToLower(q.Answer) {
+		case "true", "t":
+			q.Answer = "True"
+		case "false", "f":
+			q.Answer = "False"
+		default:
+			return false
+		}
+		q.Options = []string{"True", "False"}
+		return true
+	case models.QuestionShortAnswer, "short", "open":
+		q.Type = models.QuestionShortAnswer
+		q.Options = nil
+		return true
+	default:
+		return false
+	}
+}
+
+// extractiveQuiz builds questions by blanking key terms out of source
+// sentences, for providers that can't write questions. Distractors are other
+// terms of the same kind, so output is deterministic for a given text.
+func extractiveQuiz(text string, plan []string) []models.QuizQuestion {
+	sentences := extractive.Sentences(text)
+	terms := extractive.KeyTerms(sentences, 0)
+
+	rank := make(map[string]int, len(terms))
+	byKind := make(map[string][]string)
+	for i, t := range terms {
+		rank[t.Text] = i
+		byKind[t.Kind] = append(byKind[t.Kind], t.Text)
+	}
+
+	// Pair each usable sentence with its highest-ranked term
+	type candidate struct {
+		sentence extractive.Sentence
+		term     extractive.Term
+	}
+	var candidates []candidate
+	for _, sentence := range sentences {
+		if len(sentence.Text) < 40 || len(sentence.Text) > 300 {
+			continue
+		}
+		best := -1
+		for _, t := range terms {
+			if strings.Contains(sentence.Text, t.Text) && (best < 0 || rank[t.Text] < best) {
+				best = rank[t.Text]
+			}
+		}
+		if best >= 0 {
+			candidates = append(candidates, candidate{sentence: sentence, term: terms[best]})
+		}
+	}
+
+	// Prefer sentences about the most frequent terms, each term used once
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return rank[candidates[i].term.Text] < rank[candidates[j].term.Text]
+	})
+	var picked []candidate
+	used := make(map[string]bool)
+	for _, c := range candidates {
+		if len(picked) == len(plan) {
+			break
+		}
+		if used[c.term.Text] {
+			continue
+		}
+		used[c.term.Text] = true
+		picked = append(picked, c)
+	}
+
+	// Ask questions in reading order
+	sort.Slice(picked, func(i, j int) bool {
+		return picked[i].sentence.Index < picked[j].sentence.Index
+	})
+
+	questions := make([]models.QuizQuestion, 0, len(picked))
+	trueFalse := 0
+	for i, c := range picked {
+		answer := c.term.Text
+		blanked := extractive.Blank(c.sentence.Text, answer)
+		distractors := pickDistractors(byKind[c.term.Kind], c.sentence.Text, answer, i, 3)
+
+		q := models.QuizQuestion{
+			Answer:      answer,
+			Explanation: fmt.Sprintf("The text states: \"%s\"", c.sentence.Text),
+			SourcePage:  c.sentence.Page,
+		}
+
+		questionType := plan[i]
+		if questionType == models.QuestionMultipleChoice && len(distractors) == 0 {
+			questionType = models.QuestionShortAnswer
+		}
+
+		switch questionType {
+		case models.QuestionMultipleChoice:
+			q.Type = models.QuestionMultipleChoice
+			q.Stem = "Which answer completes the statement? " + blanked
+			q.Options = append([]string{answer}, distractors...)
+			sort.Strings(q.Options)
+		case models.QuestionTrueFalse:
+			q.Type = models.QuestionTrueFalse
+			q.Options = []string{"True", "False"}
+			q.Stem = "True or false: " + c.sentence.Text
+			q.Answer = "True"
+			// Every other statement is falsified by swapping in a distractor
+			if trueFalse%2 == 1 && len(distractors) > 0 {
+				q.Stem = "True or false: " + strings.Replace(c.sentence.Text, answer, distractors[0], 1)
+				q.Answer = "False"
+			}
+			trueFalse++
+		default:
+			q.Type = models.QuestionShortAnswer
+			q.Stem = "Fill in the blank: " + blanked
+		}
+
+		questions = append(questions, q)
+	}
+
+	return questions
+}
+
+// pickDistractors chooses up to n wrong answers from pool, rotating the
+// starting point by offset so questions don't all share the same choices
+func pickDistractors(pool []string, sentence, answer string, offset, n int) []string {
+	var eligible []string
+	for _, term := range pool {
+		if term != answer && !strings.Contains(sentence, term) && !strings.Contains(term, answer) && !strings.Contains(answer, term) {
+			eligible = append(eligible, term)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil
+	}
+
+	var picked []string
+	for i := 0; i < len(eligible) && len(picked) < n; i++ {
+		picked = append(picked, eligible[(offset*n+i)%len(eligible)])
+	}
+	return picked
+}
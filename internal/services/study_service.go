@@ -1,38 +1,67 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"studyforge/internal/config"
 	"studyforge/internal/models"
 	"studyforge/internal/repository"
 	"studyforge/pkg/ai"
+	"studyforge/pkg/chunker"
+	"studyforge/pkg/prompts"
 )
 
 // StudyService handles study material generation
 type StudyService struct {
-	aiClient    *ai.HuggingFaceClient
-	pdfService  *PDFService
-	contentRepo *repository.ContentRepository
-	docRepo     *repository.DocumentRepository
+	cfg                *config.Config
+	tokenizer          chunker.Tokenizer
+	chains             *ai.Chains
+	prompts            *prompts.Library
+	pdfService         *PDFService
+	contentRepo        *repository.ContentRepository
+	docRepo            *repository.DocumentRepository
+	flashcardRepo      *repository.FlashcardRepository
+	glossaryRepo       *repository.GlossaryRepository
+	passageRepo        *repository.PassageRepository
+	promptTemplateRepo *repository.PromptTemplateRepository
 }
 
 // NewStudyService creates a new study service
 func NewStudyService(
-	aiClient *ai.HuggingFaceClient,
+	cfg *config.Config,
+	chains *ai.Chains,
+	promptLibrary *prompts.Library,
 	pdfService *PDFService,
 	contentRepo *repository.ContentRepository,
 	docRepo *repository.DocumentRepository,
+	flashcardRepo *repository.FlashcardRepository,
+	glossaryRepo *repository.GlossaryRepository,
+	passageRepo *repository.PassageRepository,
+	promptTemplateRepo *repository.PromptTemplateRepository,
 ) *StudyService {
 	return &StudyService{
-		aiClient:    aiClient,
-		pdfService:  pdfService,
-		contentRepo: contentRepo,
-		docRepo:     docRepo,
+		cfg:                cfg,
+		tokenizer:          chunker.NewTokenizer(cfg.ChunkTokenizer),
+		chains:             chains,
+		prompts:            promptLibrary,
+		pdfService:         pdfService,
+		contentRepo:        contentRepo,
+		docRepo:            docRepo,
+		flashcardRepo:      flashcardRepo,
+		glossaryRepo:       glossaryRepo,
+		passageRepo:        passageRepo,
+		promptTemplateRepo: promptTemplateRepo,
 	}
 }
 
+// defaultSummaryTargetLength is the final summary size (in characters) used
+// when the request doesn't specify one
+const defaultSummaryTargetLength = 2500
+
 // GenerateSummaryRequest contains parameters for summary generation
 type GenerateSummaryRequest struct {
 	SessionID     string
@@ -40,44 +69,84 @@ type GenerateSummaryRequest struct {
 	PageStart     int
 	PageEnd       int
 	AcademicLevel string
+	Subject       string // subject key for prompts, or prompts.AutoSubject to detect it; defaults to general
+	TargetLength  int    // maximum characters in the final summary
+	KeepSections  bool   // store per-section summaries alongside the final summary
+	Regenerate    bool   // generate even when a cached summary of the same inputs exists
 }
 
 // GenerateSummaryResponse contains the generated summary
 type GenerateSummaryResponse struct {
-	ContentID      int    `json:"content_id"`
-	Summary        string `json:"summary"`
-	GenerationTime int    `json:"generation_time"`
-	ModelUsed      string `json:"model_used"`
+	ContentID      int        `json:"content_id"`
+	Summary        string     `json:"summary"`
+	Sections       []string   `json:"sections,omitempty"`
+	Subject        string     `json:"subject"`
+	Extractive     bool       `json:"extractive"` // sentences were selected from the source, not written by a model
+	Provider       string     `json:"provider"`   // provider that produced the summary
+	GenerationTime int        `json:"generation_time"`
+	ModelUsed      string     `json:"model_used"`
+	Cached         bool       `json:"cached"`              // an earlier summary of the same inputs was returned
+	CachedAt       *time.Time `json:"cached_at,omitempty"` // when the returned summary was generated, if cached
+}
+
+// summaryParams are the summary parameters that change its output
+type summaryParams struct {
+	Subject      string `json:"subject"`
+	TargetLength int    `json:"target_length"`
+	KeepSections bool   `json:"keep_sections"`
 }
 
-// GenerateSummary generates a summary from specified pages
-func (s *StudyService) GenerateSummary(req *GenerateSummaryRequest) (*GenerateSummaryResponse, error) {
+// GenerateSummary generates a summary from specified pages, reusing an
+// earlier summary of the same inputs unless the request asks to regenerate
+func (s *StudyService) GenerateSummary(ctx context.Context, req *GenerateSummaryRequest) (*GenerateSummaryResponse, error) {
 	startTime := time.Now()
 
-	// Get document
-	doc, err := s.docRepo.GetByID(req.DocumentID)
+	text, err := s.loadPages(ctx, req.SessionID, req.DocumentID, req.PageStart, req.PageEnd)
 	if err != nil {
-		return nil, fmt.Errorf("document not found: %w", err)
+		return nil, err
 	}
+	subject := s.resolveSubject(req.Subject, text)
 
-	// Verify session matches
-	if doc.SessionID != req.SessionID {
-		return nil, fmt.Errorf("unauthorized access to document")
+	targetLength := req.TargetLength
+	if targetLength <= 0 {
+		targetLength = defaultSummaryTargetLength
 	}
 
-	// Validate page range
-	if err := s.pdfService.ValidatePageRange(doc.FilePath, req.PageStart, req.PageEnd); err != nil {
-		return nil, err
+	inputs := generationInputs{
+		Material:      "summary",
+		Text:          text,
+		AcademicLevel: req.AcademicLevel,
+		Params:        summaryParams{Subject: subject, TargetLength: targetLength, KeepSections: req.KeepSections},
+	}
+	if !req.Regenerate {
+		cached, err := s.cachedContent(ctx, req.SessionID, inputs)
+		if err != nil {
+			return nil, err
+		}
+		if cached != nil {
+			return cachedSummary(cached)
+		}
 	}
 
-	// Extract text from PDF
-	text, _, err := s.pdfService.ExtractText(req.DocumentID, doc.FilePath, req.PageStart, req.PageEnd)
+	instruction, err := s.renderPrompt("summary", subject, req.AcademicLevel, prompts.Data{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract text: %w", err)
+		return nil, err
 	}
 
-	// Generate summary using AI
-	summary, err := s.aiClient.GenerateSummary(text, req.AcademicLevel)
+	// Generate summary using AI, re-summarizing long ranges hierarchically
+	summary := ai.SummaryOptions{AcademicLevel: req.AcademicLevel, Instruction: instruction}
+	opts := ai.HierarchicalOptions{
+		TargetLength:  targetLength,
+		KeepSections:  req.KeepSections,
+		OverlapTokens: s.cfg.ChunkOverlapTokens,
+		Tokenizer:     s.tokenizer,
+	}
+	var result *ai.HierarchicalSummary
+	modelInfo, err := s.generateWith(ctx, "summary", func(p ai.Provider) error {
+		var err error
+		result, err = ai.SummarizeHierarchical(ctx, p, text, summary, opts)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate summary: %w", err)
 	}
@@ -86,14 +155,19 @@ func (s *StudyService) GenerateSummary(req *GenerateSummaryRequest) (*GenerateSu
 
 	// Create output content structure
 	outputData := map[string]interface{}{
-		"summary":        summary,
-		"pages":          fmt.Sprintf("%d-%d", req.PageStart, req.PageEnd),
+		"summary":        result.Summary,
+		"pages":          pageRange(req.PageStart, req.PageEnd),
 		"academic_level": req.AcademicLevel,
+		"subject":        subject,
+		"passes":         result.Passes,
 	}
-
-	outputJSON, err := json.Marshal(outputData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal output: %w", err)
+	if modelInfo.Extractive {
+		outputData["extractive"] = true
+	} else {
+		outputData["prompt_version"] = s.prompts.Version()
+	}
+	if len(result.Sections) > 0 {
+		outputData["sections"] = result.Sections
 	}
 
 	// Save to database
@@ -102,28 +176,57 @@ func (s *StudyService) GenerateSummary(req *GenerateSummaryRequest) (*GenerateSu
 		DocumentID:     req.DocumentID,
 		ContentType:    "summary",
 		AcademicLevel:  req.AcademicLevel,
-		InputPages:     fmt.Sprintf("%d-%d", req.PageStart, req.PageEnd),
-		OutputContent:  string(outputJSON),
-		AIModel:        "facebook/bart-large-cnn",
+		InputPages:     pageRange(req.PageStart, req.PageEnd),
+		AIModel:        modelLabel(modelInfo, modelInfo.Model),
 		GenerationTime: generationTime,
-		CreatedAt:      time.Now(),
+		CacheKey:       s.cacheKey(inputs, modelInfo),
 	}
-
-	if err := s.contentRepo.CreateGenerated(generatedContent); err != nil {
-		return nil, fmt.Errorf("failed to save content: %w", err)
+	if err := s.saveContent(ctx, generatedContent, outputData); err != nil {
+		return nil, err
 	}
 
 	return &GenerateSummaryResponse{
 		ContentID:      generatedContent.ID,
-		Summary:        summary,
+		Summary:        result.Summary,
+		Sections:       result.Sections,
+		Subject:        subject,
+		Extractive:     modelInfo.Extractive,
+		Provider:       modelInfo.Provider,
 		GenerationTime: generationTime,
-		ModelUsed:      "facebook/bart-large-cnn",
+		ModelUsed:      modelLabel(modelInfo, modelInfo.Model),
+	}, nil
+}
+
+// cachedSummary rebuilds the response for a stored summary
+func cachedSummary(content *models.GeneratedContent) (*GenerateSummaryResponse, error) {
+	var output struct {
+		Summary    string   `json:"summary"`
+		Sections   []string `json:"sections"`
+		Subject    string   `json:"subject"`
+		Extractive bool     `json:"extractive"`
+	}
+	if err := json.Unmarshal([]byte(content.OutputContent), &output); err != nil {
+		return nil, fmt.Errorf("failed to read cached summary: %w", err)
+	}
+	provider, _, _ := strings.Cut(content.AIModel, ":")
+
+	return &GenerateSummaryResponse{
+		ContentID:      content.ID,
+		Summary:        output.Summary,
+		Sections:       output.Sections,
+		Subject:        output.Subject,
+		Extractive:     output.Extractive,
+		Provider:       provider,
+		GenerationTime: content.GenerationTime,
+		ModelUsed:      content.AIModel,
+		Cached:         true,
+		CachedAt:       &content.CreatedAt,
 	}, nil
 }
 
 // GetGeneratedContent retrieves previously generated content
-func (s *StudyService) GetGeneratedContent(contentID int, sessionID string) (*models.GeneratedContent, error) {
-	content, err := s.contentRepo.GetGeneratedByID(contentID)
+func (s *StudyService) GetGeneratedContent(ctx context.Context, contentID int, sessionID string) (*models.GeneratedContent, error) {
+	content, err := s.contentRepo.GetGeneratedByID(ctx, contentID)
 	if err != nil {
 		return nil, err
 	}
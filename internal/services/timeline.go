@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"studyforge/internal/models"
+	"studyforge/pkg/ai"
+	"studyforge/pkg/extractive"
+	"studyforge/pkg/prompts"
+)
+
+// MaxTimelineEvents caps the number of events kept in a timeline
+const MaxTimelineEvents = 200
+
+// duplicateEventSimilarity is the word overlap above which two events on the
+// same date are considered the same event
+const duplicateEventSimilarity = 0.5
+
+// GenerateTimelineRequest contains parameters for timeline generation
+type GenerateTimelineRequest struct {
+	SessionID     string
+	DocumentID    int
+	PageStart     int
+	PageEnd       int
+	AcademicLevel string
+	Subject       string
+}
+
+// GenerateTimelineResponse contains the generated timeline
+type GenerateTimelineResponse struct {
+	ContentID      int                    `json:"content_id"`
+	Events         []models.TimelineEvent `json:"events"`
+	Subject        string                 `json:"subject"`
+	Provider       string                 `json:"provider"`
+	GenerationTime int                    `json:"generation_time"`
+	ModelUsed      string                 `json:"model_used"`
+}
+
+// GenerateTimeline extracts dated events from specified pages and returns
+// them in chronological order
+func (s *StudyService) GenerateTimeline(ctx context.Context, req *GenerateTimelineRequest) (*GenerateTimelineResponse, error) {
+	startTime := time.Now()
+
+	text, err := s.loadPages(ctx, req.SessionID, req.DocumentID, req.PageStart, req.PageEnd)
+	if err != nil {
+		return nil, err
+	}
+	subject := s.resolveSubject(req.Subject, text)
+
+	var events []models.TimelineEvent
+	modelInfo, err := s.generateWith(ctx, "timeline", func(p ai.Provider) error {
+		if p.ModelInfo().Extractive {
+			events = extractiveTimeline(text)
+			return ctx.Err()
+		}
+		var err error
+		events, err = s.modelTimeline(ctx, p, text, subject, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate timeline: %w", err)
+	}
+
+	events = mergeTimeline(events)
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: no dated events found in the selected pages", ErrInvalidRequest)
+	}
+	if len(events) > MaxTimelineEvents {
+		events = events[:MaxTimelineEvents]
+	}
+
+	generationTime := int(time.Since(startTime).Milliseconds())
+
+	// Create output content structure
+	outputData := map[string]interface{}{
+		"events":         events,
+		"pages":          pageRange(req.PageStart, req.PageEnd),
+		"academic_level": req.AcademicLevel,
+		"subject":        subject,
+	}
+	if !modelInfo.Extractive {
+		outputData["prompt_version"] = s.prompts.Version()
+	}
+
+	// Save to database
+	generatedContent := &models.GeneratedContent{
+		SessionID:      req.SessionID,
+		DocumentID:     req.DocumentID,
+		ContentType:    "timeline",
+		AcademicLevel:  req.AcademicLevel,
+		InputPages:     pageRange(req.PageStart, req.PageEnd),
+		AIModel:        modelLabel(modelInfo, modelInfo.Generator()),
+		GenerationTime: generationTime,
+	}
+	if err := s.saveContent(ctx, generatedContent, outputData); err != nil {
+		return nil, err
+	}
+
+	return &GenerateTimelineResponse{
+		ContentID:      generatedContent.ID,
+		Events:         events,
+		Subject:        subject,
+		Provider:       modelInfo.Provider,
+		GenerationTime: generationTime,
+		ModelUsed:      modelLabel(modelInfo, modelInfo.Generator()),
+	}, nil
+}
+
+// modelTimeline asks the provider for the dated events in each chunk
+func (s *StudyService) modelTimeline(ctx context.Context, p ai.Provider, text, subject string, req *GenerateTimelineRequest) ([]models.TimelineEvent, error) {
+	chunks := s.promptChunks(p, text)
+	if len(chunks) == 0 {
+		return nil, errNoText
+	}
+
+	prompts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		prompt, err := s.buildTimelinePrompt(chunk.Text, subject, req.AcademicLevel)
+		if err != nil {
+			return nil, err
+		}
+		prompts[i] = prompt
+	}
+
+	var events []models.TimelineEvent
+	dropped := 0
+	err := generateJSON(ctx, p, prompts, func(i int, raw string) error {
+		var out struct {
+			Events []models.TimelineEvent `json:"events"`
+		}
+		if err := decodeJSONOutput(raw, &out); err != nil {
+			return err
+		}
+
+		chunk := chunks[i]
+		for _, e := range out.Events {
+			e.Date = strings.TrimSpace(e.Date)
+			e.Event = strings.TrimSpace(e.Event)
+			if e.Event == "" || !normalizeEventDate(&e) {
+				dropped++
+				continue
+			}
+			e.Page = clampPage(e.Page, chunk.PageStart, chunk.PageEnd, chunk.PageStart)
+			events = append(events, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if dropped > 0 {
+		log.Printf("Dropped %d timeline events without a usable date", dropped)
+	}
+	return events, nil
+}
+
+// buildTimelinePrompt asks for the dated events of a chunk as JSON
+func (s *StudyService) buildTimelinePrompt(text, subject, academicLevel string) (string, error) {
+	return s.renderPrompt("timeline", subject, academicLevel, prompts.Data{Text: text})
+}
+
+// normalizeEventDate fills in SortDate, trusting a well-formed sort_date
+// from the model and otherwise parsing the written date
+func normalizeEventDate(e *models.TimelineEvent) bool {
+	if d, ok := extractive.ParseSortableDate(e.SortDate); ok {
+		e.SortDate = d.String()
+		if e.Date == "" {
+			e.Date = e.SortDate
+		}
+		return true
+	}
+
+	if d, _, ok := extractive.ParseDate(e.Date); ok {
+		e.SortDate = d.String()
+		return true
+	}
+	return false
+}
+
+// extractiveTimeline turns every sentence that contains a date into an event,
+// with the key names in the sentence as participants
+func extractiveTimeline(text string) []models.TimelineEvent {
+	sentences := extractive.Sentences(text)
+	terms := extractive.KeyTerms(sentences, 0)
+
+	var events []models.TimelineEvent
+	for _, s := range sentences {
+		d, written, ok := extractive.ParseDate(s.Text)
+		if !ok {
+			continue
+		}
+
+		var participants []string
+		for _, t := range terms {
+			if t.Kind == extractive.TermName && strings.Contains(s.Text, t.Text) {
+				participants = append(participants, t.Text)
+			}
+		}
+
+		events = append(events, models.TimelineEvent{
+			Date:         written,
+			SortDate:     d.String(),
+			Event:        s.Text,
+			Participants: participants,
+			Page:         s.Page,
+		})
+	}
+	return events
+}
+
+// mergeTimeline merges duplicate events, which chunk overlap and repeated
+// mentions produce, and sorts the result chronologically. Events on the same
+// date with mostly the same words are duplicates; their participants are
+// combined and the earliest page is kept.
+func mergeTimeline(events []models.TimelineEvent) []models.TimelineEvent {
+	var merged []models.TimelineEvent
+	for _, e := range events {
+		duplicate := -1
+		for i := range merged {
+			if merged[i].SortDate == e.SortDate && wordOverlap(merged[i].Event, e.Event) >= duplicateEventSimilarity {
+				duplicate = i
+				break
+			}
+		}
+		if duplicate < 0 {
+			merged = append(merged, e)
+			continue
+		}
+
+		m := &merged[duplicate]
+		for _, p := range e.Participants {
+			if !containsFold(m.Participants, p) {
+				m.Participants = append(m.Participants, p)
+			}
+		}
+		if e.Page > 0 && (m.Page == 0 || e.Page < m.Page) {
+			m.Page = e.Page
+		}
+	}
+
+	dates := make(map[string]extractive.Date, len(merged))
+	for _, e := range merged {
+		dates[e.SortDate], _ = extractive.ParseSortableDate(e.SortDate)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return dates[merged[i].SortDate].Before(dates[merged[j].SortDate])
+	})
+
+	return merged
+}
+
+// wordOverlap returns the Jaccard similarity of the content words of a and b
+func wordOverlap(a, b string) float64 {
+	wordsA := make(map[string]bool)
+	for _, w := range extractive.Words(a) {
+		wordsA[w] = true
+	}
+	wordsB := make(map[string]bool)
+	for _, w := range extractive.Words(b) {
+		wordsB[w] = true
+	}
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for w := range wordsA {
+		if wordsB[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+// containsFold reports whether list includes value, ignoring case
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
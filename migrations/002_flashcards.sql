@@ -0,0 +1,28 @@
+-- StudyForge Database Schema
+-- Migration 002: Flashcards
+
+-- Individual flashcards; each deck is a generated_content row of type 'flashcards'
+CREATE TABLE IF NOT EXISTS flashcards (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    content_id INTEGER NOT NULL,
+    session_id TEXT NOT NULL,
+    document_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    front TEXT NOT NULL,
+    back TEXT NOT NULL,
+    kind TEXT,
+    source_page INTEGER,
+    box INTEGER DEFAULT 0,
+    review_count INTEGER DEFAULT 0,
+    correct_count INTEGER DEFAULT 0,
+    last_reviewed TIMESTAMP,
+    due_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (content_id) REFERENCES generated_content(id),
+    FOREIGN KEY (session_id) REFERENCES sessions(id),
+    FOREIGN KEY (document_id) REFERENCES documents(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_flashcards_content ON flashcards(content_id);
+CREATE INDEX IF NOT EXISTS idx_flashcards_session_due ON flashcards(session_id, due_at);
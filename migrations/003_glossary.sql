@@ -0,0 +1,35 @@
+-- StudyForge Database Schema
+-- Migration 003: Document glossary
+
+-- Glossary terms, one set per document
+CREATE TABLE IF NOT EXISTS glossary_terms (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    document_id INTEGER NOT NULL,
+    term TEXT NOT NULL,
+    term_key TEXT NOT NULL,
+    definition TEXT NOT NULL,
+    definition_page INTEGER,
+    ai_model TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (document_id) REFERENCES documents(id),
+    UNIQUE(document_id, term_key)
+);
+
+-- Every page a glossary term appears on
+CREATE TABLE IF NOT EXISTS glossary_occurrences (
+    term_id INTEGER NOT NULL,
+    page INTEGER NOT NULL,
+    FOREIGN KEY (term_id) REFERENCES glossary_terms(id),
+    PRIMARY KEY (term_id, page)
+);
+
+-- Pages already scanned for glossary terms, so rebuilds are incremental
+CREATE TABLE IF NOT EXISTS glossary_pages (
+    document_id INTEGER NOT NULL,
+    page INTEGER NOT NULL,
+    processed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (document_id) REFERENCES documents(id),
+    PRIMARY KEY (document_id, page)
+);
+
+CREATE INDEX IF NOT EXISTS idx_glossary_terms_document ON glossary_terms(document_id);
@@ -0,0 +1,27 @@
+-- StudyForge Database Schema
+-- Migration 004: Passage index for document Q&A
+
+-- Retrievable passages of extracted text; each lies within a single page
+CREATE TABLE IF NOT EXISTS passages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    document_id INTEGER NOT NULL,
+    page INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    embedding TEXT,
+    embedding_model TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (document_id) REFERENCES documents(id),
+    UNIQUE(document_id, page, position)
+);
+
+-- Pages already split into passages, so indexing is incremental
+CREATE TABLE IF NOT EXISTS passage_pages (
+    document_id INTEGER NOT NULL,
+    page INTEGER NOT NULL,
+    indexed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (document_id) REFERENCES documents(id),
+    PRIMARY KEY (document_id, page)
+);
+
+CREATE INDEX IF NOT EXISTS idx_passages_document ON passages(document_id);
@@ -0,0 +1,27 @@
+-- StudyForge Database Schema
+-- Migration 006: Asynchronous generation jobs
+
+-- Queued /api/study/generate requests, processed by the server's job workers
+CREATE TABLE IF NOT EXISTS generation_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    material_type TEXT NOT NULL,
+    request TEXT NOT NULL, -- JSON generation request
+    status TEXT NOT NULL DEFAULT 'queued', -- 'queued', 'running', 'succeeded', 'failed', 'cancelled'
+    progress INTEGER DEFAULT 0, -- percent complete
+    progress_message TEXT,
+    error TEXT,
+    attempts INTEGER DEFAULT 0,
+    content_id INTEGER,
+    result TEXT, -- JSON generation response
+    available_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- earliest time a queued job may run
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES sessions(id),
+    FOREIGN KEY (content_id) REFERENCES generated_content(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_generation_jobs_status ON generation_jobs(status, available_at);
+CREATE INDEX IF NOT EXISTS idx_generation_jobs_session ON generation_jobs(session_id, created_at);
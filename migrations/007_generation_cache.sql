@@ -0,0 +1,8 @@
+-- StudyForge Database Schema
+-- Migration 007: Generation cache
+
+-- Hash of everything that determines a generation's output: extracted text,
+-- material type, academic level, prompt version, model and parameters
+ALTER TABLE generated_content ADD COLUMN cache_key TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_generated_content_cache ON generated_content(session_id, cache_key);
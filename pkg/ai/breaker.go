@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"    // requests flow normally
+	BreakerOpen     = "open"      // requests are refused until the cool-down ends
+	BreakerHalfOpen = "half_open" // a single trial request decides whether to close
+)
+
+// BreakerConfig controls when a circuit breaker trips and recovers
+type BreakerConfig struct {
+	Threshold int           // consecutive failures that trip the breaker
+	Cooldown  time.Duration // time spent open before a trial request is let through
+}
+
+// Breaker wraps a Provider with a circuit breaker. Callers ask Allow before
+// using the provider and then report the outcome with Success or Failure, or
+// with Release when the attempt was abandoned before it finished.
+type Breaker struct {
+	Provider
+	name      string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time // the clock, replaced in tests
+
+	mu       sync.Mutex
+	state    string
+	failures int       // consecutive failures while closed
+	openedAt time.Time // when the breaker last tripped
+	trial    bool      // a half-open trial request is in flight
+}
+
+// NewBreaker wraps p, registered under name, with a circuit breaker
+func NewBreaker(name string, p Provider, cfg BreakerConfig) *Breaker {
+	threshold := cfg.Threshold
+	if threshold <= 0 {
+		threshold = 3
+	}
+	cooldown := cfg.Cooldown
+	if cooldown <= 0 {
+		cooldown = time.Minute
+	}
+
+	return &Breaker{
+		Provider:  p,
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     BreakerClosed,
+	}
+}
+
+// Name returns the name the provider is configured under
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the breaker's current state
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether the provider may be used. Once the cool-down has
+// passed, an open breaker lets a single trial request through.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		log.Printf("Circuit for %s half-open, sending a trial request", b.name)
+		b.state = BreakerHalfOpen
+		b.trial = true
+		return true
+	case BreakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records a successful attempt, closing the breaker
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerClosed {
+		log.Printf("Circuit for %s closed", b.name)
+	}
+	b.state = BreakerClosed
+	b.failures = 0
+	b.trial = false
+}
+
+// Failure records a failed attempt. The breaker trips after threshold
+// consecutive failures, or at once when a half-open trial fails.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		log.Printf("Circuit for %s open after %d consecutive failures, retrying in %s", b.name, b.failures, b.cooldown)
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Release abandons an allowed attempt without recording an outcome, so a
+// half-open breaker can send another trial request
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// CheckModel delegates to the wrapped provider when it supports model checks
+func (b *Breaker) CheckModel(ctx context.Context) error {
+	if checker, ok := b.Provider.(ModelChecker); ok {
+		return checker.CheckModel(ctx)
+	}
+	return nil
+}
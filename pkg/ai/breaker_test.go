@@ -0,0 +1,128 @@
+package ai
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker around the mock provider whose clock
+// stands still until the returned function advances it
+func newTestBreaker(threshold int, cooldown time.Duration) (*Breaker, func(time.Duration)) {
+	b := NewBreaker("mock", NewMockProvider(), BreakerConfig{Threshold: threshold, Cooldown: cooldown})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+// expectState fails the test when the breaker is not in state want
+func expectState(t *testing.T, b *Breaker, want string) {
+	t.Helper()
+	if got := b.State(); got != want {
+		t.Fatalf("state = %s, want %s", got, want)
+	}
+}
+
+func TestBreakerTripsAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if !b.Allow() {
+			t.Fatalf("attempt %d refused while closed", i+1)
+		}
+		b.Failure()
+		expectState(t, b, BreakerClosed)
+	}
+
+	// A success resets the count of consecutive failures
+	b.Allow()
+	b.Success()
+	for i := 0; i < 2; i++ {
+		b.Allow()
+		b.Failure()
+	}
+	expectState(t, b, BreakerClosed)
+
+	b.Allow()
+	b.Failure()
+	expectState(t, b, BreakerOpen)
+	if b.Allow() {
+		t.Error("open breaker allowed a request")
+	}
+}
+
+func TestBreakerCooldown(t *testing.T) {
+	tests := []struct {
+		name      string
+		outcome   func(b *Breaker)
+		wantState string
+	}{
+		{"trial succeeds", (*Breaker).Success, BreakerClosed},
+		{"trial fails", (*Breaker).Failure, BreakerOpen},
+		{"trial released", (*Breaker).Release, BreakerHalfOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, advance := newTestBreaker(1, time.Minute)
+			b.Allow()
+			b.Failure()
+			expectState(t, b, BreakerOpen)
+
+			advance(59 * time.Second)
+			if b.Allow() {
+				t.Fatal("breaker allowed a request before the cooldown ended")
+			}
+
+			advance(time.Second)
+			if !b.Allow() {
+				t.Fatal("breaker refused the trial request after the cooldown")
+			}
+			expectState(t, b, BreakerHalfOpen)
+			if b.Allow() {
+				t.Fatal("half-open breaker allowed a second request during the trial")
+			}
+
+			tt.outcome(b)
+			expectState(t, b, tt.wantState)
+		})
+	}
+}
+
+func TestBreakerFailedTrialRestartsCooldown(t *testing.T) {
+	b, advance := newTestBreaker(1, time.Minute)
+	b.Allow()
+	b.Failure()
+
+	advance(time.Minute)
+	b.Allow()
+	b.Failure()
+	expectState(t, b, BreakerOpen)
+
+	advance(30 * time.Second)
+	if b.Allow() {
+		t.Fatal("breaker allowed a request before the new cooldown ended")
+	}
+	advance(30 * time.Second)
+	if !b.Allow() {
+		t.Fatal("breaker refused the trial request after the new cooldown")
+	}
+	b.Success()
+	expectState(t, b, BreakerClosed)
+	if !b.Allow() {
+		t.Error("closed breaker refused a request")
+	}
+}
+
+func TestBreakerReleasedTrialAllowsAnother(t *testing.T) {
+	b, advance := newTestBreaker(1, time.Minute)
+	b.Allow()
+	b.Failure()
+	advance(time.Minute)
+
+	b.Allow()
+	b.Release()
+	if !b.Allow() {
+		t.Fatal("released trial did not let another trial through")
+	}
+	expectState(t, b, BreakerHalfOpen)
+}
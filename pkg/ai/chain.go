@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"fmt"
+)
+
+// ChainConfig lists the providers to try, in order, for each material type
+type ChainConfig struct {
+	Default   []string            // chain for material types without one of their own
+	Materials map[string][]string // chains by material type
+	Breaker   BreakerConfig
+}
+
+// Chains holds the provider chain of every material type. Each provider is
+// created once and shared by every chain that names it, so its limits and
+// circuit breaker apply across material types.
+type Chains struct {
+	providers map[string]*Breaker
+	names     []string // provider names in order of first use
+	def       []*Breaker
+	materials map[string][]*Breaker
+}
+
+// NewChains creates the providers named in cfg
+func NewChains(cfg ChainConfig, providers ProviderConfig) (*Chains, error) {
+	if len(cfg.Default) == 0 {
+		return nil, fmt.Errorf("default provider chain is empty")
+	}
+
+	c := &Chains{
+		providers: make(map[string]*Breaker),
+		materials: make(map[string][]*Breaker),
+	}
+
+	build := func(names []string) ([]*Breaker, error) {
+		var chain []*Breaker
+		seen := make(map[string]bool)
+		for _, name := range names {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			b, ok := c.providers[name]
+			if !ok {
+				p, err := NewProvider(name, providers)
+				if err != nil {
+					return nil, err
+				}
+				b = NewBreaker(name, p, cfg.Breaker)
+				c.providers[name] = b
+				c.names = append(c.names, name)
+			}
+			chain = append(chain, b)
+		}
+		return chain, nil
+	}
+
+	var err error
+	if c.def, err = build(cfg.Default); err != nil {
+		return nil, err
+	}
+	for material, names := range cfg.Materials {
+		if len(names) == 0 {
+			return nil, fmt.Errorf("provider chain for %s is empty", material)
+		}
+		if c.materials[material], err = build(names); err != nil {
+			return nil, fmt.Errorf("provider chain for %s: %w", material, err)
+		}
+	}
+
+	return c, nil
+}
+
+// For returns the providers to try for a material type, in order
+func (c *Chains) For(material string) []*Breaker {
+	if chain, ok := c.materials[material]; ok {
+		return chain
+	}
+	return c.def
+}
+
+// Primary returns the first provider of the default chain
+func (c *Chains) Primary() *Breaker {
+	return c.def[0]
+}
+
+// States reports the circuit breaker state of every provider, by name
+func (c *Chains) States() map[string]string {
+	states := make(map[string]string, len(c.names))
+	for _, name := range c.names {
+		states[name] = c.providers[name].State()
+	}
+	return states
+}
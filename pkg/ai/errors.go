@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Sentinel errors for classifying provider failures with errors.Is
+var (
+	ErrModelLoading  = errors.New("model is loading")
+	ErrRateLimited   = errors.New("rate limited")
+	ErrUnavailable   = errors.New("provider unavailable")
+	ErrUnsupported   = errors.New("not supported by provider")
+	ErrNotConfigured = errors.New("provider not configured")
+)
+
+// APIError is returned when a provider responds with a non-success status
+type APIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // server-suggested wait before retrying, if any
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// Unwrap maps the status code to a sentinel error
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusServiceUnavailable:
+		if e.RetryAfter > 0 {
+			return ErrModelLoading
+		}
+		return ErrUnavailable
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// Retryable reports whether the request may succeed if repeated
+func (e *APIError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable,
+		http.StatusBadGateway, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"studyforge/pkg/extractive"
+)
+
+// extractiveMaxInputTokens is the passage size the extractive provider
+// ranks at once; TextRank compares every pair of sentences
+const extractiveMaxInputTokens = 8000
+
+// ExtractiveProvider summarizes without a model by ranking sentences with
+// TextRank. It needs no network access, so it serves as the fallback when
+// no model provider is configured or reachable. Material generation uses the
+// extractive builders in place of Generate, which it does not support.
+type ExtractiveProvider struct{}
+
+// NewExtractiveProvider creates a new extractive provider
+func NewExtractiveProvider() *ExtractiveProvider {
+	return &ExtractiveProvider{}
+}
+
+// ModelInfo identifies the extractive provider
+func (e *ExtractiveProvider) ModelInfo() ModelInfo {
+	return ModelInfo{
+		Provider:       ProviderExtractive,
+		Model:          "textrank",
+		EmbeddingModel: "hashing",
+		MaxInputTokens: extractiveMaxInputTokens,
+		Extractive:     true,
+	}
+}
+
+// Summarize returns the most central sentences of text in their original
+// order; the instruction is ignored
+func (e *ExtractiveProvider) Summarize(ctx context.Context, text string, opts SummaryOptions) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	sentences := extractive.TextRank(extractive.Sentences(text), summarySentences(opts.AcademicLevel))
+	if len(sentences) == 0 {
+		return "", fmt.Errorf("no sentences to summarize")
+	}
+	return strings.Join(extractive.Texts(sentences), " "), nil
+}
+
+// Generate is not supported: an extractive provider cannot follow prompts
+func (e *ExtractiveProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	return "", fmt.Errorf("%w: %s provider cannot complete prompts", ErrUnsupported, ProviderExtractive)
+}
+
+// Embed returns a normalized bag-of-words vector using feature hashing
+func (e *ExtractiveProvider) Embed(ctx context.Context, text string) ([]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return hashingEmbedding(text), nil
+}
@@ -2,28 +2,80 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"time"
 )
 
+// HuggingFaceConfig holds Hugging Face Inference API settings
+type HuggingFaceConfig struct {
+	APIKey          string
+	BaseURL         string
+	Model           string // summarization model
+	GenerationModel string // text-generation model
+	EmbeddingModel  string // feature-extraction model
+	MaxInputTokens  int    // input budget per request, leaving room for the instruction
+	RequestTimeout  time.Duration
+	Retry           RetryPolicy
+	Limits          Limits
+}
+
 // HuggingFaceClient handles communication with Hugging Face API
 type HuggingFaceClient struct {
-	apiKey  string
-	baseURL string
-	client  *http.Client
+	apiKey          string
+	baseURL         string
+	model           string
+	generationModel string
+	embeddingModel  string
+	maxInputTokens  int
+	requestTimeout  time.Duration
+	retry           RetryPolicy
+	client          *http.Client
 }
 
 // NewHuggingFaceClient creates a new Hugging Face API client
-func NewHuggingFaceClient(apiKey, baseURL string) *HuggingFaceClient {
+func NewHuggingFaceClient(cfg HuggingFaceConfig) *HuggingFaceClient {
+	requestTimeout := cfg.RequestTimeout
+	if requestTimeout <= 0 {
+		requestTimeout = 30 * time.Second
+	}
+	maxInputTokens := cfg.MaxInputTokens
+	if maxInputTokens <= 0 {
+		// BART accepts 1024 tokens including the instruction prefix
+		maxInputTokens = 800
+	}
+	retry := cfg.Retry
+	if retry.BaseDelay <= 0 || retry.MaxDelay <= 0 {
+		retry = DefaultRetryPolicy()
+	}
+
 	return &HuggingFaceClient{
-		apiKey:  apiKey,
-		baseURL: baseURL,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		apiKey:          cfg.APIKey,
+		baseURL:         cfg.BaseURL,
+		model:           cfg.Model,
+		generationModel: cfg.GenerationModel,
+		embeddingModel:  cfg.EmbeddingModel,
+		maxInputTokens:  maxInputTokens,
+		requestTimeout:  requestTimeout,
+		retry:           retry,
+		// Deadlines are applied per attempt via context in makeRequest
+		client: &http.Client{},
+	}
+}
+
+// ModelInfo returns the summarization model this client serves
+func (c *HuggingFaceClient) ModelInfo() ModelInfo {
+	return ModelInfo{
+		Provider:        ProviderHuggingFace,
+		Model:           c.model,
+		GenerationModel: c.generationModel,
+		EmbeddingModel:  c.embeddingModel,
+		MaxInputTokens:  c.maxInputTokens,
 	}
 }
 
@@ -38,113 +90,21 @@ type SummaryResponse struct {
 	SummaryText string `json:"summary_text"`
 }
 
-// GenerateSummary generates a summary using the BART model with chunking
-func (c *HuggingFaceClient) GenerateSummary(text string, academicLevel string) (string, error) {
-	// BART can handle ~1024 tokens, which is roughly 3000-4000 characters
-	// We'll use 3000 as a safe limit per chunk
-	maxChunkSize := 3000
-
-	// If text is small enough, summarize directly
-	if len(text) <= maxChunkSize {
-		return c.summarizeChunk(text, academicLevel)
-	}
-
-	// Otherwise, chunk the text and summarize each chunk
-	chunks := c.chunkText(text, maxChunkSize)
-	fmt.Printf("Text too large (%d chars), splitting into %d chunks\n", len(text), len(chunks))
-
-	var chunkSummaries []string
-	for i, chunk := range chunks {
-		fmt.Printf("Summarizing chunk %d/%d (%d chars)...\n", i+1, len(chunks), len(chunk))
-
-		summary, err := c.summarizeChunk(chunk, academicLevel)
-		if err != nil {
-			return "", fmt.Errorf("failed to summarize chunk %d: %w", i+1, err)
-		}
-
-		chunkSummaries = append(chunkSummaries, summary)
-
-		// Small delay between requests to avoid rate limiting
-		if i < len(chunks)-1 {
-			time.Sleep(500 * time.Millisecond)
-		}
-	}
-
-	// If we have multiple chunk summaries, combine them
-	if len(chunkSummaries) > 1 {
-		combinedText := ""
-		for i, summary := range chunkSummaries {
-			combinedText += fmt.Sprintf("Section %d: %s\n\n", i+1, summary)
-		}
-
-		// Return combined summaries directly
-		// Note: We could re-summarize if too long, but for now just return sections
-		fmt.Printf("Combined %d summaries into final result (%d chars)\n", len(chunkSummaries), len(combinedText))
-		return combinedText, nil
-	}
-
-	return chunkSummaries[0], nil
-}
-
-// chunkText splits text into chunks of roughly equal size
-func (c *HuggingFaceClient) chunkText(text string, maxSize int) []string {
-	var chunks []string
-	minChunkSize := 200 // Minimum chunk size to avoid tiny chunks
-
-	// Split by paragraphs first (double newlines)
-	paragraphs := splitByParagraphs(text)
-
-	currentChunk := ""
-	for _, para := range paragraphs {
-		// Skip very short paragraphs (likely page markers)
-		if len(para) < 20 {
-			continue
-		}
-
-		// If adding this paragraph would exceed the limit, save current chunk
-		if len(currentChunk)+len(para) > maxSize && len(currentChunk) >= minChunkSize {
-			chunks = append(chunks, currentChunk)
-			currentChunk = para
-		} else {
-			if len(currentChunk) > 0 {
-				currentChunk += " "
-			}
-			currentChunk += para
-		}
-	}
-
-	// Add the last chunk if it's substantial
-	if len(currentChunk) >= minChunkSize {
-		chunks = append(chunks, currentChunk)
-	}
-
-	return chunks
+// GenerationResponse represents a text-generation API response
+type GenerationResponse struct {
+	GeneratedText string `json:"generated_text"`
 }
 
-// buildEducationalPrompt creates an instructional prompt for educational content summarization
-func buildEducationalPrompt(text string, academicLevel string) string {
-	// Build instruction based on academic level
-	var instruction string
-
-	switch academicLevel {
-	case "high_school":
-		instruction = "Summarize this history textbook content for high school students. Use clear language and focus on main events, key people, and important dates. Explain cause and effect relationships. Ignore citations and web references. "
-	case "undergraduate":
-		instruction = "Summarize this history textbook content for undergraduate college students. Focus on key concepts, historical events, significant figures, and their impact. Include important context and connections between events. Ignore citations and web references. "
-	case "graduate":
-		instruction = "Summarize this history textbook content for graduate students. Emphasize analytical perspectives, historiographical significance, and complex interrelationships between events and themes. Ignore citations and web references. "
-	default:
-		instruction = "Summarize this educational textbook content. Focus on key concepts, historical events, and important facts. Maintain accuracy and clarity. Ignore citations and web references. "
-	}
-
-	// Combine instruction with content
-	return instruction + text
+// Summarize summarizes a single passage; callers split longer text with
+// SummarizeHierarchical so each request stays within the model's input limit
+func (c *HuggingFaceClient) Summarize(ctx context.Context, text string, opts SummaryOptions) (string, error) {
+	return c.summarizeChunk(ctx, text, opts)
 }
 
 // summarizeChunk summarizes a single chunk of text
-func (c *HuggingFaceClient) summarizeChunk(text string, academicLevel string) (string, error) {
-	// Build instructional prompt for educational summarization
-	prompt := buildEducationalPrompt(text, academicLevel)
+func (c *HuggingFaceClient) summarizeChunk(ctx context.Context, text string, opts SummaryOptions) (string, error) {
+	// Combine instruction with content
+	prompt := opts.instruction() + " " + text
 
 	// Prepare request
 	reqBody := SummaryRequest{
@@ -156,10 +116,9 @@ func (c *HuggingFaceClient) summarizeChunk(text string, academicLevel string) (s
 		},
 	}
 
-	// Use BART model for summarization
-	modelURL := fmt.Sprintf("%s/facebook/bart-large-cnn", c.baseURL)
+	modelURL := fmt.Sprintf("%s/%s", c.baseURL, c.model)
 
-	responseData, err := c.makeRequest(modelURL, reqBody)
+	responseData, err := c.makeRequest(ctx, modelURL, reqBody)
 	if err != nil {
 		return "", err
 	}
@@ -177,64 +136,126 @@ func (c *HuggingFaceClient) summarizeChunk(text string, academicLevel string) (s
 	return responses[0].SummaryText, nil
 }
 
-// splitByParagraphs splits text into paragraphs
-func splitByParagraphs(text string) []string {
-	// Split by double newlines or page markers
-	var paragraphs []string
-	current := ""
-
-	for _, line := range splitLines(text) {
-		if line == "" || line == "---" {
-			if len(current) > 0 {
-				paragraphs = append(paragraphs, current)
-				current = ""
-			}
-		} else {
-			if len(current) > 0 {
-				current += " "
-			}
-			current += line
-		}
+// Generate completes a prompt using the configured text-generation model
+func (c *HuggingFaceClient) Generate(ctx context.Context, prompt string) (string, error) {
+	reqBody := SummaryRequest{
+		Inputs: prompt,
+		Parameters: map[string]interface{}{
+			"max_new_tokens":   512,
+			"return_full_text": false,
+		},
+	}
+
+	modelURL := fmt.Sprintf("%s/%s", c.baseURL, c.generationModel)
+
+	responseData, err := c.makeRequest(ctx, modelURL, reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	var responses []GenerationResponse
+	if err := json.Unmarshal(responseData, &responses); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	if len(current) > 0 {
-		paragraphs = append(paragraphs, current)
+	if len(responses) == 0 {
+		return "", fmt.Errorf("empty response from API")
 	}
 
-	return paragraphs
+	return responses[0].GeneratedText, nil
 }
 
-// splitLines splits text into lines
-func splitLines(text string) []string {
-	var lines []string
-	current := ""
-
-	for _, char := range text {
-		if char == '\n' {
-			lines = append(lines, current)
-			current = ""
-		} else {
-			current += string(char)
-		}
+// Embed returns a sentence embedding using the configured feature-extraction model
+func (c *HuggingFaceClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	reqBody := SummaryRequest{Inputs: text}
+
+	modelURL := fmt.Sprintf("%s/%s", c.baseURL, c.embeddingModel)
+
+	responseData, err := c.makeRequest(ctx, modelURL, reqBody)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(current) > 0 {
-		lines = append(lines, current)
+	var embedding []float64
+	if err := json.Unmarshal(responseData, &embedding); err != nil {
+		return nil, fmt.Errorf("failed to parse embedding: %w", err)
 	}
 
-	return lines
+	return embedding, nil
+}
+
+// CheckModel verifies an API key is configured; the models themselves are
+// hosted and load on first use
+func (c *HuggingFaceClient) CheckModel(ctx context.Context) error {
+	if c.apiKey == "" {
+		return fmt.Errorf("%w: HUGGINGFACE_API_KEY is not set", ErrNotConfigured)
+	}
+	return nil
 }
 
-// makeRequest makes an HTTP request to the Hugging Face API
-func (c *HuggingFaceClient) makeRequest(url string, reqBody interface{}) ([]byte, error) {
+// loadingResponse is the body returned with 503 while a model is loading
+type loadingResponse struct {
+	Error         string  `json:"error"`
+	EstimatedTime float64 `json:"estimated_time"`
+}
+
+// makeRequest makes an HTTP request to the Hugging Face API, retrying
+// transient failures (model loading, rate limiting, gateway errors) with backoff
+func (c *HuggingFaceClient) makeRequest(ctx context.Context, url string, reqBody interface{}) ([]byte, error) {
+	if err := c.CheckModel(ctx); err != nil {
+		return nil, err
+	}
+
 	// Marshal request body
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	for attempt := 0; ; attempt++ {
+		body, err := c.doRequest(ctx, url, jsonData)
+		if err == nil {
+			return body, nil
+		}
+
+		// The caller gave up; don't retry or mask the cancellation
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var hint time.Duration
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			if !apiErr.Retryable() {
+				return nil, err
+			}
+			hint = apiErr.RetryAfter
+		}
+
+		if attempt >= c.retry.MaxRetries {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt+1, err)
+		}
+
+		delay := c.retry.Delay(attempt, hint)
+		log.Printf("Hugging Face request failed (attempt %d/%d), retrying in %s: %v",
+			attempt+1, c.retry.MaxRetries+1, delay.Round(time.Millisecond), err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// doRequest performs a single attempt bounded by the per-request timeout
+func (c *HuggingFaceClient) doRequest(ctx context.Context, url string, jsonData []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
 	// Create request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -258,7 +279,18 @@ func (c *HuggingFaceClient) makeRequest(url string, reqBody interface{}) ([]byte
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			var loading loadingResponse
+			if json.Unmarshal(body, &loading) == nil && loading.EstimatedTime > 0 {
+				apiErr.RetryAfter = time.Duration(loading.EstimatedTime * float64(time.Second))
+			}
+		}
+		return nil, apiErr
 	}
 
 	return body, nil
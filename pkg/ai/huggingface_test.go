@@ -0,0 +1,223 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRetryPolicy retries quickly so tests don't wait on real backoff
+var testRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  time.Millisecond,
+	MaxDelay:   5 * time.Millisecond,
+}
+
+// newHuggingFaceTestClient returns a client for a stand-in server running
+// handler, and a count of the requests it received
+func newHuggingFaceTestClient(t *testing.T, handler http.HandlerFunc) (*HuggingFaceClient, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return NewHuggingFaceClient(HuggingFaceConfig{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		Model:           "test-summarizer",
+		GenerationModel: "test-generator",
+		EmbeddingModel:  "test-embed",
+		RequestTimeout:  5 * time.Second,
+		Retry:           testRetryPolicy,
+	}), &requests
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		min, max time.Duration
+	}{
+		{"empty", "", 0, 0},
+		{"seconds", "7", 7 * time.Second, 7 * time.Second},
+		{"zero seconds", "0", 0, 0},
+		{"HTTP date", time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat), 28 * time.Second, 30 * time.Second},
+		{"invalid", "soon", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.value)
+			if got < tt.min || got > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %s, want between %s and %s", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 4, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		name     string
+		attempt  int
+		hint     time.Duration
+		min, max time.Duration
+	}{
+		{"first backoff", 0, 0, 500 * time.Millisecond, time.Second},
+		{"doubled backoff", 2, 0, 2 * time.Second, 4 * time.Second},
+		{"backoff capped", 10, 0, 5 * time.Second, 10 * time.Second},
+		{"backoff capped on overflow", 80, 0, 5 * time.Second, 10 * time.Second},
+		{"server hint", 0, 3 * time.Second, 3 * time.Second, 4 * time.Second},
+		{"server hint capped", 0, time.Hour, 10 * time.Second, 11 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 50; i++ {
+				got := policy.Delay(tt.attempt, tt.hint)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("Delay(%d, %s) = %s, want between %s and %s", tt.attempt, tt.hint, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestHuggingFaceRetriesTransientErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header string
+		body   string
+	}{
+		{"rate limited", http.StatusTooManyRequests, "1", ""},
+		{"model loading", http.StatusServiceUnavailable, "", `{"error":"loading","estimated_time":20}`},
+		{"bad gateway", http.StatusBadGateway, "", ""},
+		{"gateway timeout", http.StatusGatewayTimeout, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			client, requests := newHuggingFaceTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				// Fail twice, then answer
+				if atomic.AddInt32(&calls, 1) <= 2 {
+					if tt.header != "" {
+						w.Header().Set("Retry-After", tt.header)
+					}
+					w.WriteHeader(tt.status)
+					fmt.Fprint(w, tt.body)
+					return
+				}
+				fmt.Fprint(w, `[{"generated_text":"Tides follow the moon."}]`)
+			})
+
+			// Server hints of seconds are capped at MaxDelay, so this is quick
+			start := time.Now()
+			got, err := client.Generate(context.Background(), "Explain tides")
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if got != "Tides follow the moon." {
+				t.Errorf("Generate = %q", got)
+			}
+			if n := atomic.LoadInt32(requests); n != 3 {
+				t.Errorf("requests = %d, want 3", n)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("retries took %s; the server hint was not capped", elapsed)
+			}
+		})
+	}
+}
+
+func TestHuggingFaceGivesUpAfterMaxRetries(t *testing.T) {
+	client, requests := newHuggingFaceTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := client.Generate(context.Background(), "Explain tides")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want ErrUnavailable", err)
+	}
+	if n := atomic.LoadInt32(requests); n != int32(testRetryPolicy.MaxRetries+1) {
+		t.Errorf("requests = %d, want %d", n, testRetryPolicy.MaxRetries+1)
+	}
+}
+
+func TestHuggingFaceDoesNotRetryClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusUnprocessableEntity} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			client, requests := newHuggingFaceTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(status)
+				fmt.Fprint(w, `{"error":"bad input"}`)
+			})
+
+			_, err := client.Generate(context.Background(), "Explain tides")
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != status {
+				t.Fatalf("err = %v, want an APIError with status %d", err, status)
+			}
+			if n := atomic.LoadInt32(requests); n != 1 {
+				t.Errorf("requests = %d, want 1", n)
+			}
+		})
+	}
+}
+
+func TestHuggingFaceRetryAfterHint(t *testing.T) {
+	date := time.Now().Add(45 * time.Second).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		name     string
+		header   string
+		min, max time.Duration
+	}{
+		{"seconds", "12", 12 * time.Second, 12 * time.Second},
+		{"HTTP date", date, 43 * time.Second, 45 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newHuggingFaceTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", tt.header)
+				w.WriteHeader(http.StatusTooManyRequests)
+			})
+			client.retry.MaxRetries = 0
+
+			_, err := client.Generate(context.Background(), "Explain tides")
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want an APIError", err)
+			}
+			if apiErr.RetryAfter < tt.min || apiErr.RetryAfter > tt.max {
+				t.Errorf("RetryAfter = %s, want between %s and %s", apiErr.RetryAfter, tt.min, tt.max)
+			}
+			if !errors.Is(err, ErrRateLimited) {
+				t.Errorf("err = %v, want ErrRateLimited", err)
+			}
+		})
+	}
+}
+
+func TestHuggingFaceRetryStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client, requests := newHuggingFaceTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	client.retry.MaxDelay = time.Minute
+
+	_, err := client.Generate(ctx, "Explain tides")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
@@ -0,0 +1,134 @@
+package ai
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limits caps how hard StudyForge drives a single provider
+type Limits struct {
+	Concurrency       int     // maximum in-flight requests
+	RequestsPerSecond float64 // maximum request rate, 0 for unlimited
+}
+
+// LimitedProvider wraps a Provider with a concurrency limit and rate limiter
+// shared by every caller, so parallel chunk processing stays within the
+// provider's limits
+type LimitedProvider struct {
+	Provider
+	sem     chan struct{}
+	limiter *rateLimiter
+}
+
+// NewLimitedProvider wraps p with the given limits
+func NewLimitedProvider(p Provider, limits Limits) *LimitedProvider {
+	concurrency := limits.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	lp := &LimitedProvider{
+		Provider: p,
+		sem:      make(chan struct{}, concurrency),
+	}
+	if limits.RequestsPerSecond > 0 {
+		lp.limiter = &rateLimiter{interval: time.Duration(float64(time.Second) / limits.RequestsPerSecond)}
+	}
+	return lp
+}
+
+// ModelInfo reports the wrapped provider's info with its concurrency limit
+func (lp *LimitedProvider) ModelInfo() ModelInfo {
+	info := lp.Provider.ModelInfo()
+	info.MaxConcurrency = cap(lp.sem)
+	return info
+}
+
+// Summarize implements Provider within the configured limits
+func (lp *LimitedProvider) Summarize(ctx context.Context, text string, opts SummaryOptions) (string, error) {
+	release, err := lp.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return lp.Provider.Summarize(ctx, text, opts)
+}
+
+// Generate implements Provider within the configured limits
+func (lp *LimitedProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	release, err := lp.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return lp.Provider.Generate(ctx, prompt)
+}
+
+// Embed implements Provider within the configured limits
+func (lp *LimitedProvider) Embed(ctx context.Context, text string) ([]float64, error) {
+	release, err := lp.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return lp.Provider.Embed(ctx, text)
+}
+
+// CheckModel delegates to the wrapped provider when it supports model checks
+func (lp *LimitedProvider) CheckModel(ctx context.Context) error {
+	if checker, ok := lp.Provider.(ModelChecker); ok {
+		return checker.CheckModel(ctx)
+	}
+	return nil
+}
+
+// acquire waits for a concurrency slot and the rate limiter
+func (lp *LimitedProvider) acquire(ctx context.Context) (func(), error) {
+	select {
+	case lp.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-lp.sem }
+
+	if lp.limiter != nil {
+		if err := lp.limiter.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// rateLimiter spaces requests at least interval apart
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// Wait blocks until the caller may issue its next request
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,245 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"studyforge/pkg/chunker"
+)
+
+// defaultMaxInputTokens is used for providers that don't report an input limit
+const defaultMaxInputTokens = 750
+
+// maxReduceDepth bounds the number of re-summarization passes
+const maxReduceDepth = 5
+
+// HierarchicalOptions controls map-reduce summarization
+type HierarchicalOptions struct {
+	TargetLength  int               // maximum characters in the final summary
+	KeepSections  bool              // return the first-pass section summaries as well
+	OverlapTokens int               // context repeated between source chunks
+	Tokenizer     chunker.Tokenizer // token estimate used to size chunks
+}
+
+// HierarchicalSummary is the result of map-reduce summarization
+type HierarchicalSummary struct {
+	Summary  string   `json:"summary"`
+	Sections []string `json:"sections,omitempty"` // per-chunk summaries from the map pass
+	Passes   int      `json:"passes"`             // number of summarization passes performed
+}
+
+// SummarizeHierarchical summarizes text of any length. Text larger than the
+// provider's input limit is split into chunks which are summarized (map), and
+// the chunk summaries are then recursively re-summarized (reduce) until a
+// single summary no longer than opts.TargetLength remains. Every pass is
+// written as described by summary.
+func SummarizeHierarchical(ctx context.Context, p Provider, text string, summary SummaryOptions, opts HierarchicalOptions) (*HierarchicalSummary, error) {
+	chunkOpts := chunker.Options{
+		MaxTokens:     p.ModelInfo().MaxInputTokens,
+		OverlapTokens: opts.OverlapTokens,
+		Tokenizer:     opts.Tokenizer,
+	}
+	if chunkOpts.MaxTokens <= 0 {
+		chunkOpts.MaxTokens = defaultMaxInputTokens
+	}
+	if chunkOpts.Tokenizer == nil {
+		chunkOpts.Tokenizer = chunker.CharEstimator{}
+	}
+	fits := func(s string) bool {
+		return chunkOpts.Tokenizer.CountTokens(s) <= chunkOpts.MaxTokens
+	}
+
+	// If text is small enough, summarize directly
+	if fits(text) {
+		result, err := p.Summarize(ctx, text, summary)
+		if err != nil {
+			return nil, err
+		}
+		Report(ctx, Event{Type: EventProgress, Stage: StageChunk, Message: "Chunk 1/1 summarized", Done: 1, Total: 1})
+		return &HierarchicalSummary{Summary: result, Passes: 1}, nil
+	}
+
+	// Map: summarize each chunk of the source text
+	chunks := chunker.Texts(chunker.Split(text, chunkOpts))
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no summarizable content in text")
+	}
+	log.Printf("Text too large (%d tokens), splitting into %d chunks", chunkOpts.Tokenizer.CountTokens(text), len(chunks))
+
+	sections, err := summarizeChunks(ctx, p, chunks, summary)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &HierarchicalSummary{Passes: 1}
+	if opts.KeepSections {
+		result.Sections = sections
+	}
+
+	// Reduce: re-summarize until a single summary fits the target length.
+	// Summaries are not re-read with overlap; it would only duplicate them.
+	reduceOpts := chunkOpts
+	reduceOpts.OverlapTokens = 0
+
+	current := sections
+	for result.Passes < maxReduceDepth {
+		combined := strings.Join(current, "\n\n")
+		if len(current) == 1 && (opts.TargetLength <= 0 || len(combined) <= opts.TargetLength) {
+			break
+		}
+
+		var next []string
+		if fits(combined) {
+			synthesis, err := p.Summarize(ctx, combined, summary)
+			if err != nil {
+				return nil, fmt.Errorf("failed to synthesize summary: %w", err)
+			}
+			next = []string{synthesis}
+		} else {
+			next, err = summarizeChunks(ctx, p, chunker.Texts(chunker.Split(combined, reduceOpts)), summary)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result.Passes++
+
+		// Stop if the model isn't shrinking its input any further
+		shrunk := len(strings.Join(next, "\n\n")) < len(combined)
+		current = next
+		if !shrunk {
+			break
+		}
+		log.Printf("Reduce pass %d: %d summaries (%d chars)", result.Passes, len(current), len(strings.Join(current, "\n\n")))
+		Report(ctx, Event{
+			Type:    EventProgress,
+			Stage:   StageReduce,
+			Message: fmt.Sprintf("Reduce pass %d: %d summaries", result.Passes, len(current)),
+			Done:    result.Passes,
+		})
+	}
+
+	result.Summary = strings.Join(current, "\n\n")
+	return result, nil
+}
+
+// summarizeChunks summarizes chunks in parallel and returns the summaries
+// in chunk order
+func summarizeChunks(ctx context.Context, p Provider, chunks []string, opts SummaryOptions) ([]string, error) {
+	summaries := make([]string, len(chunks))
+	var done int32
+	err := runParallel(ctx, p, len(chunks), func(ctx context.Context, i int) error {
+		log.Printf("Summarizing chunk %d/%d (%d chars)...", i+1, len(chunks), len(chunks[i]))
+
+		summary, err := p.Summarize(withPart(ctx, i+1), chunks[i], opts)
+		if err != nil {
+			return fmt.Errorf("failed to summarize chunk %d: %w", i+1, err)
+		}
+		summaries[i] = summary
+		reportChunk(ctx, &done, len(chunks), fmt.Sprintf("Chunk %d/%d summarized", i+1, len(chunks)))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// GenerateAll runs each prompt through the provider in parallel and returns
+// the outputs in prompt order
+func GenerateAll(ctx context.Context, p Provider, prompts []string) ([]string, error) {
+	outputs := make([]string, len(prompts))
+	var done int32
+	err := runParallel(ctx, p, len(prompts), func(ctx context.Context, i int) error {
+		log.Printf("Generating part %d/%d...", i+1, len(prompts))
+
+		output, err := p.Generate(withPart(ctx, i+1), prompts[i])
+		if err != nil {
+			return fmt.Errorf("failed to generate part %d: %w", i+1, err)
+		}
+		outputs[i] = output
+		reportChunk(ctx, &done, len(prompts), fmt.Sprintf("Chunk %d/%d generated", i+1, len(prompts)))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
+
+// EmbedAll embeds each text in parallel and returns the vectors in text order
+func EmbedAll(ctx context.Context, p Provider, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	err := runParallel(ctx, p, len(texts), func(ctx context.Context, i int) error {
+		vector, err := p.Embed(ctx, texts[i])
+		if err != nil {
+			return fmt.Errorf("failed to embed passage %d: %w", i+1, err)
+		}
+		vectors[i] = vector
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+// reportChunk counts a finished chunk and reports it to the listener
+func reportChunk(ctx context.Context, done *int32, total int, message string) {
+	n := atomic.AddInt32(done, 1)
+	Report(ctx, Event{Type: EventProgress, Stage: StageChunk, Message: message, Done: int(n), Total: total})
+}
+
+// runParallel calls fn for indexes 0..n-1, bounded by the provider's
+// concurrency limit. The first failure cancels the remaining work.
+func runParallel(ctx context.Context, p Provider, n int, fn func(ctx context.Context, i int) error) error {
+	workers := p.ModelInfo().MaxConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i := 0; i < n; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,120 @@
+package ai
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+
+	"studyforge/pkg/extractive"
+)
+
+// hashingEmbeddingDims is the size of vectors returned by hashingEmbedding
+const hashingEmbeddingDims = 256
+
+// MockProvider is a deterministic, offline provider for development and tests.
+// All output is extracted from the input text, so identical input always
+// produces identical output and no network access is required.
+type MockProvider struct{}
+
+// NewMockProvider creates a new mock provider
+func NewMockProvider() *MockProvider {
+	return &MockProvider{}
+}
+
+// ModelInfo identifies the mock provider
+func (m *MockProvider) ModelInfo() ModelInfo {
+	// Mirror the Hugging Face limit so demos exercise the map-reduce path
+	return ModelInfo{
+		Provider:       ProviderMock,
+		Model:          "mock-extractive",
+		EmbeddingModel: "mock-hashing",
+		MaxInputTokens: 800,
+		Extractive:     true,
+	}
+}
+
+// Summarize returns the highest-ranked sentences in their original order
+func (m *MockProvider) Summarize(ctx context.Context, text string, opts SummaryOptions) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	sentences := topSentences(text, summarySentences(opts.AcademicLevel))
+	if streaming(ctx) {
+		// Stream a sentence at a time so the UI's incremental rendering can
+		// be exercised offline
+		for i, sentence := range sentences {
+			if i > 0 {
+				sentence = " " + sentence
+			}
+			reportDelta(ctx, sentence)
+		}
+	}
+	return strings.Join(sentences, " "), nil
+}
+
+// Generate returns the highest-ranked sentences of the prompt as bullet points
+func (m *MockProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, sentence := range topSentences(prompt, 5) {
+		b.WriteString("- ")
+		b.WriteString(sentence)
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Embed returns a normalized bag-of-words vector using feature hashing
+func (m *MockProvider) Embed(ctx context.Context, text string) ([]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return hashingEmbedding(text), nil
+}
+
+// topSentences returns the highest-ranked sentences of text in document order
+func topSentences(text string, count int) []string {
+	return extractive.Texts(extractive.TopSentences(extractive.Sentences(text), count))
+}
+
+// summarySentences is how many sentences an extractive summary has at an
+// academic level
+func summarySentences(academicLevel string) int {
+	switch academicLevel {
+	case "high_school":
+		return 4
+	case "graduate":
+		return 7
+	default:
+		return 5
+	}
+}
+
+// hashingEmbedding returns a normalized bag-of-words vector using feature
+// hashing
+func hashingEmbedding(text string) []float64 {
+	vec := make([]float64, hashingEmbeddingDims)
+	for _, word := range extractive.Words(text) {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		vec[h.Sum32()%hashingEmbeddingDims]++
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += v * v
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vec {
+			vec[i] /= norm
+		}
+	}
+	return vec
+}
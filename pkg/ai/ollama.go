@@ -0,0 +1,327 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// OllamaConfig holds settings for a local Ollama server
+type OllamaConfig struct {
+	BaseURL        string // e.g. http://localhost:11434
+	Model          string
+	EmbeddingModel string
+	Temperature    float64
+	ContextTokens  int  // num_ctx passed to the model
+	PullMissing    bool // pull models that are not installed during CheckModel
+	Limits         Limits
+}
+
+// OllamaClient talks to the Ollama REST API
+type OllamaClient struct {
+	baseURL        string
+	model          string
+	embeddingModel string
+	temperature    float64
+	contextTokens  int
+	pullMissing    bool
+	client         *http.Client
+	longClient     *http.Client // model pulls and streams, bounded only by the caller's context
+	noEmbedding    atomic.Bool  // set by CheckModel when the embedding model is missing
+}
+
+// NewOllamaClient creates a new Ollama API client
+func NewOllamaClient(cfg OllamaConfig) *OllamaClient {
+	embeddingModel := cfg.EmbeddingModel
+	if embeddingModel == "" {
+		embeddingModel = cfg.Model
+	}
+
+	return &OllamaClient{
+		baseURL:        strings.TrimRight(cfg.BaseURL, "/"),
+		model:          cfg.Model,
+		embeddingModel: embeddingModel,
+		temperature:    cfg.Temperature,
+		contextTokens:  cfg.ContextTokens,
+		pullMissing:    cfg.PullMissing,
+		client: &http.Client{
+			// Local models on laptops can be slow, especially on first load
+			Timeout: 5 * time.Minute,
+		},
+		// Pulling a multi-GB model or streaming a long response can outlast
+		// any fixed timeout; these requests stop when their context ends
+		longClient: &http.Client{},
+	}
+}
+
+// ollamaOutputTokens caps the length of each generated response
+const ollamaOutputTokens = 1024
+
+// OllamaGenerateRequest represents an /api/generate request
+type OllamaGenerateRequest struct {
+	Model   string                 `json:"model"`
+	Prompt  string                 `json:"prompt"`
+	System  string                 `json:"system,omitempty"`
+	Stream  bool                   `json:"stream"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// OllamaGenerateResponse represents an /api/generate response
+type OllamaGenerateResponse struct {
+	Model    string `json:"model"`
+	Response string `json:"response"`
+	Done     bool   `json:"done"`
+	Error    string `json:"error,omitempty"` // set when a streamed response fails part way
+}
+
+// OllamaEmbeddingRequest represents an /api/embeddings request
+type OllamaEmbeddingRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+// OllamaEmbeddingResponse represents an /api/embeddings response
+type OllamaEmbeddingResponse struct {
+	Embedding []float64 `json:"embedding"`
+}
+
+// OllamaTagsResponse represents the /api/tags model listing
+type OllamaTagsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// ModelInfo returns the generation model this client serves
+func (c *OllamaClient) ModelInfo() ModelInfo {
+	return ModelInfo{
+		Provider:       ProviderOllama,
+		Model:          c.model,
+		EmbeddingModel: c.embeddingModel,
+		MaxInputTokens: inputBudget(c.contextTokens, ollamaOutputTokens),
+	}
+}
+
+// Summarize summarizes a single passage that fits within MaxInputTokens
+func (c *OllamaClient) Summarize(ctx context.Context, text string, opts SummaryOptions) (string, error) {
+	return c.generate(ctx, opts.instruction(), text)
+}
+
+// Generate completes a free-form instruction prompt
+func (c *OllamaClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.generate(ctx, "", prompt)
+}
+
+// Embed returns an embedding from /api/embeddings
+func (c *OllamaClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	if c.noEmbedding.Load() {
+		return nil, fmt.Errorf("%w: embedding model %q is not installed", ErrUnsupported, c.embeddingModel)
+	}
+
+	reqBody := OllamaEmbeddingRequest{
+		Model:  c.embeddingModel,
+		Prompt: text,
+	}
+
+	responseData, err := c.makeRequest(ctx, c.client, "POST", c.baseURL+"/api/embeddings", reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp OllamaEmbeddingResponse
+	if err := json.Unmarshal(responseData, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(resp.Embedding) == 0 {
+		return nil, fmt.Errorf("empty embedding from API")
+	}
+
+	return resp.Embedding, nil
+}
+
+// CheckModel verifies the configured models are installed, pulling them if
+// enabled. Only a missing generation model is an error; without the
+// embedding model, Embed fails and answers fall back to keyword retrieval.
+func (c *OllamaClient) CheckModel(ctx context.Context) error {
+	installed, err := c.listModels(ctx)
+	if err != nil {
+		return fmt.Errorf("ollama unreachable at %s: %w", c.baseURL, err)
+	}
+
+	if err := c.ensureModel(ctx, installed, c.model); err != nil {
+		return err
+	}
+	if c.embeddingModel != c.model {
+		if err := c.ensureModel(ctx, installed, c.embeddingModel); err != nil {
+			log.Printf("Warning: embeddings unavailable: %v", err)
+			c.noEmbedding.Store(true)
+		}
+	}
+
+	return nil
+}
+
+// ensureModel pulls model when it is not installed and pulling is enabled
+func (c *OllamaClient) ensureModel(ctx context.Context, installed []string, model string) error {
+	if hasModel(installed, model) {
+		return nil
+	}
+	if !c.pullMissing {
+		return fmt.Errorf("model %q is not installed (run: ollama pull %s)", model, model)
+	}
+	if err := c.pullModel(ctx, model); err != nil {
+		return fmt.Errorf("failed to pull model %q: %w", model, err)
+	}
+	return nil
+}
+
+// generate sends an /api/generate request, streaming the response when the
+// caller listens for output deltas
+func (c *OllamaClient) generate(ctx context.Context, system, prompt string) (string, error) {
+	reqBody := OllamaGenerateRequest{
+		Model:  c.model,
+		Prompt: prompt,
+		System: strings.TrimSpace(system),
+		Stream: false,
+		Options: map[string]interface{}{
+			"temperature": c.temperature,
+			"num_predict": ollamaOutputTokens,
+			"num_ctx":     c.contextTokens,
+		},
+	}
+	if streaming(ctx) {
+		reqBody.Stream = true
+		return c.generateStream(ctx, reqBody)
+	}
+
+	responseData, err := c.makeRequest(ctx, c.client, "POST", c.baseURL+"/api/generate", reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	var resp OllamaGenerateResponse
+	if err := json.Unmarshal(responseData, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return strings.TrimSpace(resp.Response), nil
+}
+
+// generateStream reads a streamed /api/generate response, one JSON object
+// per line, reporting each piece of text as it arrives
+func (c *OllamaClient) generateStream(ctx context.Context, reqBody OllamaGenerateRequest) (string, error) {
+	body, err := openStream(ctx, c.longClient, c.baseURL+"/api/generate", reqBody, nil)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	var b strings.Builder
+	dec := json.NewDecoder(body)
+	for {
+		var resp OllamaGenerateResponse
+		if err := dec.Decode(&resp); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("failed to parse response: %w", err)
+		}
+		if resp.Error != "" {
+			return "", fmt.Errorf("generation failed: %s", resp.Error)
+		}
+
+		reportDelta(ctx, resp.Response)
+		b.WriteString(resp.Response)
+		if resp.Done {
+			break
+		}
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+// listModels returns the names of locally installed models
+func (c *OllamaClient) listModels(ctx context.Context) ([]string, error) {
+	responseData, err := c.makeRequest(ctx, c.client, "GET", c.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp OllamaTagsResponse
+	if err := json.Unmarshal(responseData, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	names := make([]string, 0, len(resp.Models))
+	for _, m := range resp.Models {
+		names = append(names, m.Name)
+	}
+	return names, nil
+}
+
+// pullModel downloads a model, blocking until the pull completes
+func (c *OllamaClient) pullModel(ctx context.Context, model string) error {
+	reqBody := map[string]interface{}{
+		"name":   model,
+		"stream": false,
+	}
+
+	_, err := c.makeRequest(ctx, c.longClient, "POST", c.baseURL+"/api/pull", reqBody)
+	return err
+}
+
+// hasModel reports whether model is installed, treating a missing tag as ":latest"
+func hasModel(installed []string, model string) bool {
+	if !strings.Contains(model, ":") {
+		model += ":latest"
+	}
+	for _, name := range installed {
+		if name == model {
+			return true
+		}
+	}
+	return false
+}
+
+// makeRequest makes an HTTP request to the Ollama API with client
+func (c *OllamaClient) makeRequest(ctx context.Context, client *http.Client, method, url string, reqBody interface{}) ([]byte, error) {
+	var bodyReader io.Reader
+	if reqBody != nil {
+		jsonData, err := json.Marshal(reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		bodyReader = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
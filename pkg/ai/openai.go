@@ -0,0 +1,266 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIConfig holds settings for an OpenAI-compatible API (OpenAI, llama.cpp, vLLM)
+type OpenAIConfig struct {
+	BaseURL        string // e.g. http://localhost:8000/v1
+	APIKey         string // optional for local servers
+	Model          string
+	EmbeddingModel string
+	Temperature    float64
+	MaxTokens      int // completion tokens
+	ContextTokens  int // model context window
+	Limits         Limits
+}
+
+// OpenAIClient talks to servers exposing the OpenAI chat-completions protocol
+type OpenAIClient struct {
+	baseURL        string
+	apiKey         string
+	model          string
+	embeddingModel string
+	temperature    float64
+	maxTokens      int
+	contextTokens  int
+	client         *http.Client
+	streamClient   *http.Client // streamed completions, bounded only by the caller's context
+}
+
+// NewOpenAIClient creates a new OpenAI-compatible API client
+func NewOpenAIClient(cfg OpenAIConfig) *OpenAIClient {
+	embeddingModel := cfg.EmbeddingModel
+	if embeddingModel == "" {
+		embeddingModel = cfg.Model
+	}
+
+	return &OpenAIClient{
+		baseURL:        strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:         cfg.APIKey,
+		model:          cfg.Model,
+		embeddingModel: embeddingModel,
+		temperature:    cfg.Temperature,
+		maxTokens:      cfg.MaxTokens,
+		contextTokens:  cfg.ContextTokens,
+		client: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+		// A streamed completion keeps producing output for as long as the
+		// model writes; it stops when the request's context ends
+		streamClient: &http.Client{},
+	}
+}
+
+// ChatMessage is a single message in a chat-completions conversation
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatCompletionRequest represents a /chat/completions request
+type ChatCompletionRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Stream      bool          `json:"stream,omitempty"`
+}
+
+// ChatCompletionResponse represents a /chat/completions response
+type ChatCompletionResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message ChatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// ChatCompletionChunk is one server-sent event of a streamed /chat/completions response
+type ChatCompletionChunk struct {
+	Choices []struct {
+		Delta ChatMessage `json:"delta"`
+	} `json:"choices"`
+}
+
+// EmbeddingRequest represents an /embeddings request
+type EmbeddingRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+// EmbeddingResponse represents an /embeddings response
+type EmbeddingResponse struct {
+	Data []struct {
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// ModelInfo returns the chat model this client serves
+func (c *OpenAIClient) ModelInfo() ModelInfo {
+	return ModelInfo{
+		Provider:       ProviderOpenAI,
+		Model:          c.model,
+		EmbeddingModel: c.embeddingModel,
+		MaxInputTokens: inputBudget(c.contextTokens, c.maxTokens),
+	}
+}
+
+// Summarize summarizes a single passage that fits within MaxInputTokens
+func (c *OpenAIClient) Summarize(ctx context.Context, text string, opts SummaryOptions) (string, error) {
+	return c.chat(ctx, opts.instruction(), text)
+}
+
+// Generate completes a free-form instruction prompt
+func (c *OpenAIClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.chat(ctx, "", prompt)
+}
+
+// Embed returns an embedding from the /embeddings endpoint
+func (c *OpenAIClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	reqBody := EmbeddingRequest{
+		Model: c.embeddingModel,
+		Input: text,
+	}
+
+	responseData, err := c.makeRequest(ctx, c.baseURL+"/embeddings", reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp EmbeddingResponse
+	if err := json.Unmarshal(responseData, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("empty response from API")
+	}
+
+	return resp.Data[0].Embedding, nil
+}
+
+// chat sends a single-turn chat completion with an optional system message,
+// streaming the response when the caller listens for output deltas
+func (c *OpenAIClient) chat(ctx context.Context, system, user string) (string, error) {
+	var messages []ChatMessage
+	if system != "" {
+		messages = append(messages, ChatMessage{Role: "system", Content: strings.TrimSpace(system)})
+	}
+	messages = append(messages, ChatMessage{Role: "user", Content: user})
+
+	reqBody := ChatCompletionRequest{
+		Model:       c.model,
+		Messages:    messages,
+		Temperature: c.temperature,
+		MaxTokens:   c.maxTokens,
+	}
+	if streaming(ctx) {
+		reqBody.Stream = true
+		return c.chatStream(ctx, reqBody)
+	}
+
+	responseData, err := c.makeRequest(ctx, c.baseURL+"/chat/completions", reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	var resp ChatCompletionResponse
+	if err := json.Unmarshal(responseData, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("empty response from API")
+	}
+
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// chatStream reads a streamed chat completion, sent as server-sent events
+// ending with "[DONE]", reporting each piece of text as it arrives
+func (c *OpenAIClient) chatStream(ctx context.Context, reqBody ChatCompletionRequest) (string, error) {
+	header := http.Header{}
+	if c.apiKey != "" {
+		header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	}
+	body, err := openStream(ctx, c.streamClient, c.baseURL+"/chat/completions", reqBody, header)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	var b strings.Builder
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk ChatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("failed to parse response: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			reportDelta(ctx, choice.Delta.Content)
+			b.WriteString(choice.Delta.Content)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if b.Len() == 0 {
+		return "", fmt.Errorf("empty response from API")
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// makeRequest makes an HTTP request to the OpenAI-compatible API
+func (c *OpenAIClient) makeRequest(ctx context.Context, url string, reqBody interface{}) ([]byte, error) {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
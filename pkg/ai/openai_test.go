@@ -0,0 +1,197 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newOpenAITestClient returns a client for a stand-in server running handler
+func newOpenAITestClient(t *testing.T, handler http.HandlerFunc) *OpenAIClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewOpenAIClient(OpenAIConfig{
+		BaseURL:        server.URL + "/v1/",
+		APIKey:         "test-key",
+		Model:          "test-model",
+		EmbeddingModel: "test-embed",
+		Temperature:    0.2,
+		MaxTokens:      128,
+		ContextTokens:  4096,
+	})
+}
+
+// decodeRequest decodes a JSON request body, failing the test on error
+func decodeRequest(t *testing.T, r *http.Request, v interface{}) {
+	t.Helper()
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		t.Errorf("failed to decode request: %v", err)
+	}
+}
+
+func TestOpenAIGenerate(t *testing.T) {
+	client := newOpenAITestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %q, want /v1/chat/completions", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q, want Bearer test-key", got)
+		}
+
+		var req ChatCompletionRequest
+		decodeRequest(t, r, &req)
+		if req.Model != "test-model" || req.MaxTokens != 128 || req.Stream {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		if len(req.Messages) != 1 || req.Messages[0].Role != "user" || req.Messages[0].Content != "Explain tides" {
+			t.Errorf("messages = %+v, want a single user message", req.Messages)
+		}
+
+		fmt.Fprint(w, `{"model":"test-model","choices":[{"message":{"role":"assistant","content":"  Tides are caused by the moon.  "}}]}`)
+	})
+
+	got, err := client.Generate(context.Background(), "Explain tides")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got != "Tides are caused by the moon." {
+		t.Errorf("Generate = %q", got)
+	}
+}
+
+func TestOpenAISummarizeSendsInstruction(t *testing.T) {
+	client := newOpenAITestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req ChatCompletionRequest
+		decodeRequest(t, r, &req)
+		if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[0].Content != "Summarize briefly." {
+			t.Errorf("messages = %+v, want the instruction as a system message", req.Messages)
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"Short summary."}}]}`)
+	})
+
+	got, err := client.Summarize(context.Background(), "Long text.", SummaryOptions{Instruction: "Summarize briefly."})
+	if err != nil {
+		t.Fatalf("Summarize: %v", err)
+	}
+	if got != "Short summary." {
+		t.Errorf("Summarize = %q", got)
+	}
+}
+
+func TestOpenAIStreamReportsDeltas(t *testing.T) {
+	client := newOpenAITestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req ChatCompletionRequest
+		decodeRequest(t, r, &req)
+		if !req.Stream {
+			t.Errorf("stream = false, want true when the context wants deltas")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, piece := range []string{"Tides ", "follow ", "the moon."} {
+			chunk, _ := json.Marshal(map[string]interface{}{
+				"choices": []map[string]interface{}{{"delta": map[string]string{"content": piece}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	var mu sync.Mutex
+	var deltas []string
+	ctx := WithDeltas(WithListener(context.Background(), func(e Event) {
+		if e.Type != EventDelta {
+			return
+		}
+		mu.Lock()
+		deltas = append(deltas, e.Text)
+		mu.Unlock()
+	}))
+
+	got, err := client.Generate(ctx, "Explain tides")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got != "Tides follow the moon." {
+		t.Errorf("Generate = %q", got)
+	}
+	if strings.Join(deltas, "|") != "Tides |follow |the moon." {
+		t.Errorf("deltas = %q", deltas)
+	}
+}
+
+func TestOpenAIEmbed(t *testing.T) {
+	client := newOpenAITestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("path = %q, want /v1/embeddings", r.URL.Path)
+		}
+		var req EmbeddingRequest
+		decodeRequest(t, r, &req)
+		if req.Model != "test-embed" || req.Input != "tides" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		fmt.Fprint(w, `{"data":[{"embedding":[0.1,0.2,0.3]}]}`)
+	})
+
+	got, err := client.Embed(context.Background(), "tides")
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(got) != 3 || got[0] != 0.1 || got[2] != 0.3 {
+		t.Errorf("Embed = %v", got)
+	}
+}
+
+func TestOpenAIErrorStatus(t *testing.T) {
+	const body = `{"error":{"message":"model not found"}}`
+	client := newOpenAITestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, body)
+	})
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{"chat", func(ctx context.Context) error {
+			_, err := client.Generate(ctx, "Explain tides")
+			return err
+		}},
+		{"stream", func(ctx context.Context) error {
+			_, err := client.Generate(WithDeltas(WithListener(ctx, func(Event) {})), "Explain tides")
+			return err
+		}},
+		{"embed", func(ctx context.Context) error {
+			_, err := client.Embed(ctx, "tides")
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(context.Background())
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), "status 404") || !strings.Contains(err.Error(), "model not found") {
+				t.Errorf("error = %q, want the status and response body", err)
+			}
+		})
+	}
+}
+
+func TestOpenAIEmptyResponse(t *testing.T) {
+	client := newOpenAITestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[]}`)
+	})
+
+	if _, err := client.Generate(context.Background(), "Explain tides"); err == nil {
+		t.Fatal("expected an error for a response without choices")
+	}
+}
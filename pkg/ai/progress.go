@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"context"
+)
+
+// Progress event types
+const (
+	EventProgress = "progress" // a generation step finished
+	EventDelta    = "delta"    // a provider wrote more output text
+)
+
+// Progress stages
+const (
+	StageExtraction = "extraction" // source pages extracted
+	StageChunk      = "chunk"      // a chunk of the source summarized or generated
+	StageReduce     = "reduce"     // a reduce pass over chunk summaries finished
+	StageFallback   = "fallback"   // a provider failed; its output so far is discarded
+)
+
+// Event reports the progress of a generation request
+type Event struct {
+	Type    string `json:"type"`
+	Stage   string `json:"stage,omitempty"`
+	Message string `json:"message,omitempty"`
+	Done    int    `json:"done,omitempty"`  // steps finished in the stage
+	Total   int    `json:"total,omitempty"` // steps in the stage
+	Part    int    `json:"part,omitempty"`  // 1-based chunk a delta belongs to, 0 for a single request
+	Text    string `json:"text,omitempty"`  // output text of a delta
+}
+
+// Listener receives progress events. Chunks are processed in parallel, so
+// listeners must be safe for concurrent use.
+type Listener func(Event)
+
+type listenerKey struct{}
+type deltasKey struct{}
+type partKey struct{}
+
+// WithListener returns a context whose generation requests report progress
+// to l
+func WithListener(ctx context.Context, l Listener) context.Context {
+	return context.WithValue(ctx, listenerKey{}, l)
+}
+
+// WithDeltas returns a context whose generation requests stream their output,
+// reporting deltas to the context's listener. Providers that cannot stream
+// answer as usual.
+func WithDeltas(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deltasKey{}, true)
+}
+
+// Report sends an event to the context's listener, if any
+func Report(ctx context.Context, e Event) {
+	if l, ok := ctx.Value(listenerKey{}).(Listener); ok {
+		l(e)
+	}
+}
+
+// streaming reports whether the context wants output deltas
+func streaming(ctx context.Context) bool {
+	_, ok := ctx.Value(listenerKey{}).(Listener)
+	deltas, _ := ctx.Value(deltasKey{}).(bool)
+	return ok && deltas
+}
+
+// reportDelta sends a delta of output text, tagged with the context's part
+func reportDelta(ctx context.Context, text string) {
+	if text == "" {
+		return
+	}
+	part, _ := ctx.Value(partKey{}).(int)
+	Report(ctx, Event{Type: EventDelta, Part: part, Text: text})
+}
+
+// withPart tags deltas reported under ctx with a 1-based chunk number
+func withPart(ctx context.Context, part int) context.Context {
+	return context.WithValue(ctx, partKey{}, part)
+}
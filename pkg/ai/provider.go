@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+)
+
+// Provider is implemented by every AI backend StudyForge can talk to
+type Provider interface {
+	// Summarize produces an educational summary of a single passage as
+	// described by opts; see SummarizeHierarchical for long text
+	Summarize(ctx context.Context, text string, opts SummaryOptions) (string, error)
+
+	// Generate completes a free-form instruction prompt
+	Generate(ctx context.Context, prompt string) (string, error)
+
+	// Embed returns a vector embedding for text
+	Embed(ctx context.Context, text string) ([]float64, error)
+
+	// ModelInfo describes the backend and model serving requests
+	ModelInfo() ModelInfo
+}
+
+// defaultSummaryInstruction is used when a summary request carries no
+// instruction of its own
+const defaultSummaryInstruction = "Summarize this educational textbook content. Focus on key concepts and important facts. Maintain accuracy and clarity. Ignore citations and web references."
+
+// SummaryOptions describes the summary a provider should write
+type SummaryOptions struct {
+	AcademicLevel string // 'high_school', 'undergraduate' or 'graduate'
+	Instruction   string // summarization prompt placed before the text
+}
+
+// instruction returns the summarization prompt, falling back to a generic one
+func (o SummaryOptions) instruction() string {
+	if o.Instruction == "" {
+		return defaultSummaryInstruction
+	}
+	return o.Instruction
+}
+
+// ModelChecker is implemented by providers that can verify their model is
+// available before serving requests
+type ModelChecker interface {
+	CheckModel(ctx context.Context) error
+}
+
+// ModelInfo identifies the provider and model that produced output
+type ModelInfo struct {
+	Provider        string `json:"provider"`
+	Model           string `json:"model"`
+	GenerationModel string `json:"generation_model,omitempty"` // model serving Generate, when different from Model
+	EmbeddingModel  string `json:"embedding_model,omitempty"`  // model serving Embed
+	MaxInputTokens  int    `json:"max_input_tokens,omitempty"` // largest passage accepted per request
+	MaxConcurrency  int    `json:"max_concurrency,omitempty"`  // parallel requests allowed
+	Extractive      bool   `json:"extractive,omitempty"`       // output is selected from the input, not written by a model
+}
+
+// Generator returns the name of the model that serves Generate
+func (m ModelInfo) Generator() string {
+	if m.GenerationModel != "" {
+		return m.GenerationModel
+	}
+	return m.Model
+}
+
+// promptReserveTokens is held back from a context window for instructions
+const promptReserveTokens = 256
+
+// inputBudget returns how many tokens of source text fit in a context window
+// after reserving room for the completion and the instruction prompt
+func inputBudget(contextTokens, outputTokens int) int {
+	budget := contextTokens - outputTokens - promptReserveTokens
+	if budget < 256 {
+		return 256
+	}
+	return budget
+}
+
+// Supported provider names
+const (
+	ProviderHuggingFace = "huggingface"
+	ProviderOpenAI      = "openai"
+	ProviderOllama      = "ollama"
+	ProviderMock        = "mock"
+	ProviderExtractive  = "extractive"
+)
+
+// ProviderConfig holds the settings for every supported provider
+type ProviderConfig struct {
+	HuggingFace HuggingFaceConfig
+	OpenAI      OpenAIConfig
+	Ollama      OllamaConfig
+}
+
+// NewProvider creates the provider registered under name, wrapped in its
+// configured concurrency and rate limits
+func NewProvider(name string, cfg ProviderConfig) (Provider, error) {
+	switch name {
+	case ProviderHuggingFace, "":
+		// Without an API key the client fails its model check and every
+		// request, so the misconfiguration shows and the chain falls back
+		return NewLimitedProvider(NewHuggingFaceClient(cfg.HuggingFace), cfg.HuggingFace.Limits), nil
+	case ProviderOpenAI:
+		if cfg.OpenAI.Model == "" {
+			return nil, fmt.Errorf("openai provider requires a model")
+		}
+		return NewLimitedProvider(NewOpenAIClient(cfg.OpenAI), cfg.OpenAI.Limits), nil
+	case ProviderOllama:
+		return NewLimitedProvider(NewOllamaClient(cfg.Ollama), cfg.Ollama.Limits), nil
+	case ProviderMock:
+		return NewLimitedProvider(NewMockProvider(), Limits{Concurrency: 8}), nil
+	case ProviderExtractive:
+		return NewExtractiveProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown AI provider: %s", name)
+	}
+}
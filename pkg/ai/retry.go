@@ -0,0 +1,60 @@
+package ai
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed provider requests are retried
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt
+	BaseDelay  time.Duration // first backoff delay, doubled on each retry
+	MaxDelay   time.Duration // upper bound for any single wait
+}
+
+// DefaultRetryPolicy returns the retry budget used when none is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: 4,
+		BaseDelay:  500 * time.Millisecond,
+		MaxDelay:   60 * time.Second,
+	}
+}
+
+// Delay returns how long to wait before retry number attempt (0-based).
+// A server hint such as estimated_time or Retry-After takes precedence over
+// exponential backoff; either way the result is capped at MaxDelay and jittered.
+func (p RetryPolicy) Delay(attempt int, hint time.Duration) time.Duration {
+	delay := hint
+	if delay <= 0 {
+		delay = p.BaseDelay << attempt
+		if delay <= 0 || delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+		// Equal jitter: wait between half and the full backoff
+		half := delay / 2
+		return half + time.Duration(rand.Int63n(int64(half)+1))
+	}
+
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	// Small jitter on top of the server hint so clients don't retry in lockstep
+	return delay + time.Duration(rand.Int63n(int64(p.BaseDelay)+1))
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
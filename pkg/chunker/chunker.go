@@ -0,0 +1,345 @@
+package chunker
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Page is the text of a single PDF page
+type Page struct {
+	Number int    // 1-indexed page number, 0 when the text had no page markers
+	Text   string // page text without the marker line
+}
+
+// Chunk is a contiguous slice of source text sized to fit a model's input
+type Chunk struct {
+	Index     int    `json:"index"`
+	Text      string `json:"text"`
+	PageStart int    `json:"page_start"`
+	PageEnd   int    `json:"page_end"`
+	Tokens    int    `json:"tokens"`
+}
+
+// Options controls how text is split into chunks
+type Options struct {
+	MaxTokens     int       // token budget per chunk
+	OverlapTokens int       // tokens of trailing context repeated at the start of the next chunk
+	Tokenizer     Tokenizer // defaults to CharEstimator
+	PageMarkers   bool      // emit "--- Page N ---" markers in chunk text
+}
+
+// pageMarkerRe matches the page separators emitted by pdf.Extractor.ExtractText
+var pageMarkerRe = regexp.MustCompile(`(?m)^--- Page (\d+) ---[ \t]*$`)
+
+var paragraphRe = regexp.MustCompile(`\n[ \t]*\n`)
+
+// abbreviations that end in a period without ending a sentence
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "st": true, "jr": true,
+	"sr": true, "vs": true, "etc": true, "e.g": true, "i.e": true, "c": true,
+	"ca": true, "cf": true, "no": true, "vol": true, "fig": true, "gen": true,
+}
+
+// ParsePages splits extracted text on its page markers. Text without markers
+// is returned as a single page numbered 0.
+func ParsePages(text string) []Page {
+	matches := pageMarkerRe.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		return []Page{{Number: 0, Text: text}}
+	}
+
+	var pages []Page
+	if lead := text[:matches[0][0]]; strings.TrimSpace(lead) != "" {
+		pages = append(pages, Page{Number: 0, Text: lead})
+	}
+
+	for i, m := range matches {
+		number, _ := strconv.Atoi(text[m[2]:m[3]])
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		pages = append(pages, Page{Number: number, Text: text[m[1]:end]})
+	}
+
+	return pages
+}
+
+// SplitSentences splits a paragraph into sentences, keeping terminal
+// punctuation. Decimals, initials and common abbreviations are not treated
+// as sentence ends.
+func SplitSentences(text string) []string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c != '.' && c != '!' && c != '?' {
+			continue
+		}
+
+		// Include closing quotes and brackets in the sentence
+		end := i + 1
+		for end < len(text) {
+			if strings.IndexByte(`"')]`, text[end]) >= 0 {
+				end++
+			} else if strings.HasPrefix(text[end:], "”") || strings.HasPrefix(text[end:], "’") {
+				end += len("”")
+			} else {
+				break
+			}
+		}
+
+		if end >= len(text) {
+			continue
+		}
+
+		// PDF extraction often drops the space between sentences
+		// ("in 1492.The"), so accept a capital directly after the period
+		// when the preceding character is lowercase or a digit
+		gap := 1
+		if text[end] != ' ' {
+			prev, _ := utf8.DecodeLastRuneInString(text[:i])
+			next, _ := utf8.DecodeRuneInString(text[end:])
+			if end != i+1 || !unicode.IsUpper(next) || !(unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+				continue
+			}
+			gap = 0
+		}
+		if end+gap >= len(text) {
+			continue
+		}
+
+		// A boundary needs something that can start a sentence
+		next, _ := utf8.DecodeRuneInString(text[end+gap:])
+		if !unicode.IsUpper(next) && !unicode.IsDigit(next) && !strings.ContainsRune(`"'(“‘`, next) {
+			continue
+		}
+		if c == '.' && isAbbreviation(text[start:i]) {
+			continue
+		}
+
+		sentences = append(sentences, text[start:end])
+		start = end + gap
+		i = start - 1
+	}
+
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// isAbbreviation reports whether the word before a period is an abbreviation or initial
+func isAbbreviation(prefix string) bool {
+	word := prefix
+	if idx := strings.LastIndexByte(prefix, ' '); idx >= 0 {
+		word = prefix[idx+1:]
+	}
+	word = strings.TrimLeft(word, `"'(`)
+
+	if utf8.RuneCountInString(word) == 1 {
+		r, _ := utf8.DecodeRuneInString(word)
+		return unicode.IsUpper(r)
+	}
+	return abbreviations[strings.ToLower(word)]
+}
+
+// unit is the smallest piece of text the chunker moves around: a sentence,
+// or a slice of an over-long sentence
+type unit struct {
+	text      string
+	page      int
+	paraStart bool // first unit of a paragraph
+	pageStart bool // first unit of a page
+	tokens    int
+}
+
+// Split breaks text into chunks of at most opts.MaxTokens tokens, breaking
+// on sentence boundaries and preferring page boundaries. Every sentence of
+// the input appears in at least one chunk; nothing is discarded.
+func Split(text string, opts Options) []Chunk {
+	tok := opts.Tokenizer
+	if tok == nil {
+		tok = CharEstimator{}
+	}
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 750
+	}
+	overlap := opts.OverlapTokens
+	if overlap >= maxTokens/2 {
+		overlap = maxTokens / 2
+	}
+
+	units, pageTokens := buildUnits(text, maxTokens, tok)
+
+	var chunks []Chunk
+	var current []unit
+	currentTokens := 0
+
+	flush := func(next unit) {
+		chunks = append(chunks, buildChunk(len(chunks), current, opts.PageMarkers, tok))
+
+		// Carry trailing sentences forward as overlap, leaving room for next
+		carried := []unit{}
+		carriedTokens := 0
+		for i := len(current) - 1; i >= 0; i-- {
+			u := current[i]
+			if carriedTokens+u.tokens > overlap || carriedTokens+u.tokens+next.tokens > maxTokens {
+				break
+			}
+			u.pageStart, u.paraStart = false, false
+			carried = append([]unit{u}, carried...)
+			carriedTokens += u.tokens
+		}
+		current = carried
+		currentTokens = carriedTokens
+	}
+
+	for _, u := range units {
+		if len(current) > 0 {
+			overflow := currentTokens+u.tokens > maxTokens
+
+			// Break before a page that won't fit rather than splitting it,
+			// as long as the current chunk is already reasonably full
+			pageBreak := u.pageStart && currentTokens >= maxTokens/2 &&
+				currentTokens+pageTokens[u.page] > maxTokens && pageTokens[u.page] <= maxTokens
+
+			if overflow || pageBreak {
+				flush(u)
+			}
+		}
+		current = append(current, u)
+		currentTokens += u.tokens
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, buildChunk(len(chunks), current, opts.PageMarkers, tok))
+	}
+
+	return chunks
+}
+
+// buildUnits splits text into sentence units, slicing sentences that exceed
+// the token budget. It also returns the total tokens on each page.
+func buildUnits(text string, maxTokens int, tok Tokenizer) ([]unit, map[int]int) {
+	var units []unit
+	pageTokens := make(map[int]int)
+
+	for _, page := range ParsePages(text) {
+		firstOnPage := true
+		for _, para := range paragraphRe.Split(page.Text, -1) {
+			firstInPara := true
+			for _, sentence := range SplitSentences(para) {
+				for _, piece := range splitOversized(sentence, maxTokens, tok) {
+					u := unit{
+						text:      piece,
+						page:      page.Number,
+						paraStart: firstInPara,
+						pageStart: firstOnPage,
+						tokens:    tok.CountTokens(piece),
+					}
+					units = append(units, u)
+					pageTokens[page.Number] += u.tokens
+					firstOnPage, firstInPara = false, false
+				}
+			}
+		}
+	}
+
+	return units, pageTokens
+}
+
+// splitOversized breaks a sentence that exceeds maxTokens into word runs
+// that fit, falling back to raw slicing for a single enormous word
+func splitOversized(sentence string, maxTokens int, tok Tokenizer) []string {
+	if tok.CountTokens(sentence) <= maxTokens {
+		return []string{sentence}
+	}
+
+	var pieces []string
+	var current []string
+	for _, word := range strings.Fields(sentence) {
+		candidate := strings.Join(append(current, word), " ")
+		if len(current) > 0 && tok.CountTokens(candidate) > maxTokens {
+			pieces = append(pieces, strings.Join(current, " "))
+			current = nil
+		}
+
+		if tok.CountTokens(word) > maxTokens {
+			pieces = append(pieces, splitRunes(word, maxTokens, tok)...)
+			continue
+		}
+		current = append(current, word)
+	}
+	if len(current) > 0 {
+		pieces = append(pieces, strings.Join(current, " "))
+	}
+
+	return pieces
+}
+
+// splitRunes slices a single word into pieces that fit the budget
+func splitRunes(word string, maxTokens int, tok Tokenizer) []string {
+	var pieces []string
+	runes := []rune(word)
+	start := 0
+	for start < len(runes) {
+		end := start + 1
+		for end < len(runes) && tok.CountTokens(string(runes[start:end+1])) <= maxTokens {
+			end++
+		}
+		pieces = append(pieces, string(runes[start:end]))
+		start = end
+	}
+	return pieces
+}
+
+// buildChunk joins units back into text, restoring paragraph breaks and,
+// optionally, page markers
+func buildChunk(index int, units []unit, pageMarkers bool, tok Tokenizer) Chunk {
+	var b strings.Builder
+	for i, u := range units {
+		newPage := i == 0 || u.page != units[i-1].page
+
+		if pageMarkers && u.page > 0 && newPage {
+			if b.Len() > 0 {
+				b.WriteString("\n\n")
+			}
+			b.WriteString(fmt.Sprintf("--- Page %d ---\n", u.page))
+		} else if i > 0 {
+			if u.paraStart || newPage {
+				b.WriteString("\n\n")
+			} else {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(u.text)
+	}
+
+	text := b.String()
+	return Chunk{
+		Index:     index,
+		Text:      text,
+		PageStart: units[0].page,
+		PageEnd:   units[len(units)-1].page,
+		Tokens:    tok.CountTokens(text),
+	}
+}
+
+// Texts returns just the text of each chunk
+func Texts(chunks []Chunk) []string {
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+	return texts
+}
@@ -0,0 +1,211 @@
+package chunker
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// sentenceTokens is the size of every test sentence under wordTokenizer
+const sentenceTokens = 7
+
+// wordTokenizer counts one token per word, so budgets are exact
+var wordTokenizer = WordEstimator{TokensPerWord: 1}
+
+// testSentence returns the unique sentence at a position in the test text
+func testSentence(page, n int) string {
+	return fmt.Sprintf("Page %d sentence %d describes the treaty.", page, n)
+}
+
+// testText builds extracted text with page markers, starting a paragraph
+// every three sentences
+func testText(pages, perPage int) string {
+	var b strings.Builder
+	for p := 1; p <= pages; p++ {
+		fmt.Fprintf(&b, "--- Page %d ---\n", p)
+		for n := 1; n <= perPage; n++ {
+			b.WriteString(testSentence(p, n))
+			if n%3 == 0 {
+				b.WriteString("\n\n")
+			} else {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
+
+// chunkSentences returns the sentences of a chunk in order, with the page
+// each appears under
+func chunkSentences(c Chunk) (sentences []string, pages []int) {
+	for _, page := range ParsePages(c.Text) {
+		for _, s := range SplitSentences(page.Text) {
+			sentences = append(sentences, s)
+			pages = append(pages, page.Number)
+		}
+	}
+	return sentences, pages
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		pages   int
+		perPage int
+		opts    Options
+	}{
+		{"single chunk", 1, 3, Options{MaxTokens: 100}},
+		{"no overlap", 4, 6, Options{MaxTokens: 30}},
+		{"overlap", 4, 6, Options{MaxTokens: 30, OverlapTokens: 10}},
+		{"overlap of several sentences", 3, 9, Options{MaxTokens: 40, OverlapTokens: 15}},
+		{"overlap capped at half the budget", 3, 9, Options{MaxTokens: 30, OverlapTokens: 100}},
+		{"page markers", 5, 4, Options{MaxTokens: 35, OverlapTokens: 7, PageMarkers: true}},
+		{"pages larger than the budget", 2, 12, Options{MaxTokens: 25, OverlapTokens: 7, PageMarkers: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Tokenizer = wordTokenizer
+			chunks := Split(testText(tt.pages, tt.perPage), opts)
+			if len(chunks) == 0 {
+				t.Fatal("Split returned no chunks")
+			}
+
+			overlap := opts.OverlapTokens
+			if overlap > opts.MaxTokens/2 {
+				overlap = opts.MaxTokens / 2
+			}
+
+			seen := make(map[string]bool)
+			var prev []string
+			for i, c := range chunks {
+				if c.Index != i {
+					t.Errorf("chunk %d: Index = %d", i, c.Index)
+				}
+				sentences, pages := chunkSentences(c)
+				if !opts.PageMarkers && len(sentences)*sentenceTokens > opts.MaxTokens {
+					t.Errorf("chunk %d: %d tokens of sentences, budget %d", i, len(sentences)*sentenceTokens, opts.MaxTokens)
+				}
+
+				// Page markers place every sentence on its source page
+				if opts.PageMarkers {
+					if want := fmt.Sprintf("--- Page %d ---\n", c.PageStart); !strings.HasPrefix(c.Text, want) {
+						t.Errorf("chunk %d does not start with %q: %q", i, want, c.Text)
+					}
+					for j, s := range sentences {
+						if !strings.HasPrefix(s, fmt.Sprintf("Page %d ", pages[j])) {
+							t.Errorf("chunk %d: %q appears under page %d", i, s, pages[j])
+						}
+						if pages[j] < c.PageStart || pages[j] > c.PageEnd {
+							t.Errorf("chunk %d: page %d outside %d-%d", i, pages[j], c.PageStart, c.PageEnd)
+						}
+					}
+				}
+
+				// Sentences repeated from the previous chunk are its tail,
+				// within the overlap budget
+				shared := overlapStart(prev, sentences)
+				if shared > len(sentences) || strings.Join(sentences[:shared], " ") != strings.Join(prev[len(prev)-shared:], " ") {
+					t.Errorf("chunk %d does not start with the tail of chunk %d", i, i-1)
+					shared = 0
+				}
+				if shared*sentenceTokens > overlap {
+					t.Errorf("chunk %d repeats %d tokens, overlap %d", i, shared*sentenceTokens, overlap)
+				}
+				if i > 0 && overlap >= sentenceTokens && shared == 0 {
+					t.Errorf("chunk %d repeats nothing of the previous chunk, overlap %d", i, overlap)
+				}
+				for _, s := range sentences[shared:] {
+					if seen[s] {
+						t.Errorf("chunk %d repeats %q outside the overlap", i, s)
+					}
+					seen[s] = true
+				}
+				prev = sentences
+			}
+
+			// No sentence of the source is lost
+			for p := 1; p <= tt.pages; p++ {
+				for n := 1; n <= tt.perPage; n++ {
+					if s := testSentence(p, n); !seen[s] {
+						t.Errorf("%q is missing from every chunk", s)
+					}
+				}
+			}
+		})
+	}
+}
+
+// overlapStart returns how many trailing sentences of prev the next chunk
+// starts with, 0 when it starts with new text
+func overlapStart(prev, next []string) int {
+	if len(next) == 0 {
+		return 0
+	}
+	for i := range prev {
+		if prev[i] == next[0] {
+			return len(prev) - i
+		}
+	}
+	return 0
+}
+
+func TestSplitKeepsPagesTogether(t *testing.T) {
+	// Pages of 28 tokens fit the budget on their own but not two at a time
+	chunks := Split(testText(4, 4), Options{MaxTokens: 50, Tokenizer: wordTokenizer, PageMarkers: true})
+	if len(chunks) != 4 {
+		t.Fatalf("got %d chunks, want one per page", len(chunks))
+	}
+	for i, c := range chunks {
+		if c.PageStart != i+1 || c.PageEnd != i+1 {
+			t.Errorf("chunk %d covers pages %d-%d, want %d", i, c.PageStart, c.PageEnd, i+1)
+		}
+	}
+}
+
+func TestSplitOversizedSentence(t *testing.T) {
+	words := make([]string, 50)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%d", i)
+	}
+	text := strings.Join(words, " ") + "."
+
+	chunks := Split(text, Options{MaxTokens: 12, Tokenizer: wordTokenizer})
+	var got []string
+	for _, c := range chunks {
+		if c.Tokens > 12 {
+			t.Errorf("chunk %d has %d tokens, budget 12", c.Index, c.Tokens)
+		}
+		got = append(got, strings.Fields(c.Text)...)
+	}
+	if strings.Join(got, " ") != text {
+		t.Errorf("chunks rejoin to %q, want %q", strings.Join(got, " "), text)
+	}
+}
+
+func TestParsePages(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []int
+	}{
+		{"markers", "--- Page 3 ---\nA.\n--- Page 4 ---\nB.", []int{3, 4}},
+		{"no markers", "Plain text.", []int{0}},
+		{"text before the first marker", "Intro.\n--- Page 1 ---\nA.", []int{0, 1}},
+		{"empty", "  \n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := ParsePages(tt.text)
+			var got []int
+			for _, p := range pages {
+				got = append(got, p.Number)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
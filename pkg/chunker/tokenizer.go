@@ -0,0 +1,51 @@
+package chunker
+
+import (
+	"math"
+	"strings"
+)
+
+// Tokenizer estimates how many model tokens a piece of text will consume
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// CharEstimator approximates tokens from character count. English text
+// averages roughly four characters per token for BPE-style tokenizers.
+type CharEstimator struct {
+	CharsPerToken float64
+}
+
+// CountTokens implements Tokenizer
+func (e CharEstimator) CountTokens(text string) int {
+	perToken := e.CharsPerToken
+	if perToken <= 0 {
+		perToken = 4
+	}
+	return int(math.Ceil(float64(len(text)) / perToken))
+}
+
+// WordEstimator approximates tokens from word count, which is more stable
+// than character counts for text with long words or heavy punctuation
+type WordEstimator struct {
+	TokensPerWord float64
+}
+
+// CountTokens implements Tokenizer
+func (e WordEstimator) CountTokens(text string) int {
+	perWord := e.TokensPerWord
+	if perWord <= 0 {
+		perWord = 1.33
+	}
+	return int(math.Ceil(float64(len(strings.Fields(text))) * perWord))
+}
+
+// NewTokenizer returns the estimator registered under name ("chars" or "words")
+func NewTokenizer(name string) Tokenizer {
+	switch name {
+	case "words":
+		return WordEstimator{}
+	default:
+		return CharEstimator{}
+	}
+}
@@ -0,0 +1,84 @@
+package extractive
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Cloze turns sentence into a cloze deletion by wrapping up to max of the
+// given terms in {{cN::term}} markers, numbered in reading order. Terms are
+// tried in the order given, so earlier terms win when two overlap, and only
+// whole-word matches count. It returns the cloze text and the deleted terms,
+// or no answers when none of the terms appear.
+func Cloze(sentence string, terms []string, max int) (string, []string) {
+	type span struct{ start, end int }
+
+	var spans []span
+	for _, term := range terms {
+		if len(spans) == max {
+			break
+		}
+		start := wordIndex(sentence, term)
+		if start < 0 {
+			continue
+		}
+		s := span{start, start + len(term)}
+
+		overlaps := false
+		for _, other := range spans {
+			if s.start < other.end && other.start < s.end {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			spans = append(spans, s)
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var b strings.Builder
+	var answers []string
+	last := 0
+	for i, s := range spans {
+		answer := sentence[s.start:s.end]
+		b.WriteString(sentence[last:s.start])
+		fmt.Fprintf(&b, "{{c%d::%s}}", i+1, answer)
+		answers = append(answers, answer)
+		last = s.end
+	}
+	b.WriteString(sentence[last:])
+
+	return b.String(), answers
+}
+
+// wordIndex returns the index of the first whole-word occurrence of term in
+// s, or -1
+func wordIndex(s, term string) int {
+	if term == "" {
+		return -1
+	}
+	for offset := 0; offset < len(s); {
+		i := strings.Index(s[offset:], term)
+		if i < 0 {
+			return -1
+		}
+		start, end := offset+i, offset+i+len(term)
+
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return start
+		}
+		offset = end
+	}
+	return -1
+}
+
+// isWordRune reports whether r is part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,149 @@
+package extractive
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Date is a calendar date of varying precision. Month and Day are 0 when
+// unknown; years before the common era are negative.
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// String formats the date as a sortable ISO-style string: "1492",
+// "1492-10" or "1492-10-12", with BCE years as "-0500"
+func (d Date) String() string {
+	year := fmt.Sprintf("%04d", d.Year)
+	if d.Year < 0 {
+		year = fmt.Sprintf("-%04d", -d.Year)
+	}
+	switch {
+	case d.Month == 0:
+		return year
+	case d.Day == 0:
+		return fmt.Sprintf("%s-%02d", year, d.Month)
+	default:
+		return fmt.Sprintf("%s-%02d-%02d", year, d.Month, d.Day)
+	}
+}
+
+// Before reports whether d sorts before other. A less precise date sorts
+// before a more precise one in the same period.
+func (d Date) Before(other Date) bool {
+	if d.Year != other.Year {
+		return d.Year < other.Year
+	}
+	if d.Month != other.Month {
+		return d.Month < other.Month
+	}
+	return d.Day < other.Day
+}
+
+var months = map[string]int{
+	"january": 1, "february": 2, "march": 3, "april": 4, "may": 5, "june": 6,
+	"july": 7, "august": 8, "september": 9, "october": 10, "november": 11, "december": 12,
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "jun": 6, "jul": 7, "aug": 8,
+	"sep": 9, "sept": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+const monthPattern = `(January|February|March|April|May|June|July|August|September|October|November|December|Jan|Feb|Mar|Apr|Jun|Jul|Aug|Sept?|Oct|Nov|Dec)\.?`
+
+// datePatterns are tried from most to least specific
+var datePatterns = []struct {
+	re    *regexp.Regexp
+	parse func(m []string) (Date, bool)
+}{
+	// 1492-10-12
+	{regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`), func(m []string) (Date, bool) {
+		return makeDate(atoi(m[1]), atoi(m[2]), atoi(m[3]))
+	}},
+	// October 12, 1492
+	{regexp.MustCompile(`\b` + monthPattern + `\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{1,4})\b`), func(m []string) (Date, bool) {
+		return makeDate(atoi(m[3]), months[strings.ToLower(m[1])], atoi(m[2]))
+	}},
+	// 12 October 1492
+	{regexp.MustCompile(`\b(\d{1,2})(?:st|nd|rd|th)?\s+` + monthPattern + `,?\s+(\d{1,4})\b`), func(m []string) (Date, bool) {
+		return makeDate(atoi(m[3]), months[strings.ToLower(m[2])], atoi(m[1]))
+	}},
+	// October 1492
+	{regexp.MustCompile(`\b` + monthPattern + `,?\s+(\d{3,4})\b`), func(m []string) (Date, bool) {
+		return makeDate(atoi(m[2]), months[strings.ToLower(m[1])], 0)
+	}},
+	// 500 BC, 44 B.C.E.
+	{regexp.MustCompile(`\b(\d{1,4})\s*(?:BCE|BC|B\.C\.E\.|B\.C\.)`), func(m []string) (Date, bool) {
+		return makeDate(-atoi(m[1]), 0, 0)
+	}},
+	// AD 33, 800 CE
+	{regexp.MustCompile(`\b(?:AD|A\.D\.)\s*(\d{1,4})\b|\b(\d{1,4})\s*(?:CE|AD|A\.D\.)(?:\W|$)`), func(m []string) (Date, bool) {
+		if m[1] != "" {
+			return makeDate(atoi(m[1]), 0, 0)
+		}
+		return makeDate(atoi(m[2]), 0, 0)
+	}},
+	// 1490s
+	{regexp.MustCompile(`\b(1[0-9]{2}0|20[0-9]0)s\b`), func(m []string) (Date, bool) {
+		return makeDate(atoi(m[1]), 0, 0)
+	}},
+	// 1492
+	{regexp.MustCompile(`\b(1[0-9]{3}|20[0-9]{2})\b`), func(m []string) (Date, bool) {
+		return makeDate(atoi(m[1]), 0, 0)
+	}},
+}
+
+// ParseDate finds the most specific date in text and returns it along with
+// the text it was parsed from
+func ParseDate(text string) (Date, string, bool) {
+	for _, p := range datePatterns {
+		m := p.re.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		if d, ok := p.parse(m); ok {
+			return d, strings.TrimSpace(m[0]), true
+		}
+	}
+	return Date{}, "", false
+}
+
+// ParseSortableDate parses a date previously formatted by Date.String
+func ParseSortableDate(s string) (Date, bool) {
+	s = strings.TrimSpace(s)
+	sign := 1
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	}
+
+	parts := strings.Split(s, "-")
+	if len(parts) == 0 || len(parts) > 3 || parts[0] == "" {
+		return Date{}, false
+	}
+	values := make([]int, 3)
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return Date{}, false
+		}
+		values[i] = v
+	}
+	return makeDate(sign*values[0], values[1], values[2])
+}
+
+// makeDate validates date components
+func makeDate(year, month, day int) (Date, bool) {
+	if year == 0 || month < 0 || month > 12 || day < 0 || day > 31 || (month == 0 && day != 0) {
+		return Date{}, false
+	}
+	return Date{Year: year, Month: month, Day: day}, true
+}
+
+// atoi converts a string of digits already matched by a pattern
+func atoi(s string) int {
+	v, _ := strconv.Atoi(s)
+	return v
+}
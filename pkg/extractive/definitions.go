@@ -0,0 +1,50 @@
+package extractive
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Definition is a sentence that defines a term
+type Definition struct {
+	Term string
+	Text string // the defining sentence
+	Page int
+}
+
+// definitionPatterns capture the defined term in group 1
+var definitionPatterns = []*regexp.Regexp{
+	// "Mercantilism is an economic theory...", "The Columbian Exchange was the..."
+	regexp.MustCompile(`^(?:The |A |An )?([\p{Lu}][\p{L}'-]*(?:\s+(?:of|the|and|de)?\s*[\p{Lu}\p{Ll}][\p{L}'-]*){0,4}?)\s+(?:is|are|was|were)\s+(?:a|an|the|one of the)\s`),
+	// "Encomienda refers to...", "Reconquista means..."
+	regexp.MustCompile(`^(?:The |A |An )?([\p{Lu}][\p{L}'-]*(?:\s+[\p{L}'-]+){0,4}?)\s+(?:refers to|means|describes|is defined as|is known as)\s`),
+	// "...a system known as the encomienda", "...a practice called mercantilism"
+	regexp.MustCompile(`\b(?:known as|called|termed)\s+(?:the\s+)?["“]?([\p{L}'-]+(?:\s+(?:of\s+)?[\p{Lu}][\p{L}'-]*){0,3})["”]?`),
+}
+
+// Definitions finds sentences that define a term, such as "X is a ..." or
+// "... known as X". Only the first definition of each term is returned.
+func Definitions(sentences []Sentence) []Definition {
+	var defs []Definition
+	seen := make(map[string]bool)
+
+	for _, s := range sentences {
+		for _, re := range definitionPatterns {
+			m := re.FindStringSubmatch(s.Text)
+			if m == nil {
+				continue
+			}
+
+			term := strings.Trim(m[1], ` ,.;:"'“”`)
+			key := strings.ToLower(term)
+			if term == "" || seen[key] || stopWords[key] || len(term) > 60 {
+				continue
+			}
+			seen[key] = true
+			defs = append(defs, Definition{Term: term, Text: s.Text, Page: s.Page})
+			break
+		}
+	}
+
+	return defs
+}
@@ -0,0 +1,160 @@
+package extractive
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"studyforge/pkg/chunker"
+)
+
+// Sentence is a sentence of source text with the page it came from
+type Sentence struct {
+	Index int    // position in the document
+	Text  string // sentence text
+	Page  int    // 0 when the source had no page markers
+}
+
+// Term kinds returned by KeyTerms
+const (
+	TermName = "name" // capitalized phrase: person, place, event, concept
+	TermDate = "date" // year or year-like number
+)
+
+// Term is a key term found in the source text
+type Term struct {
+	Text      string `json:"text"`
+	Kind      string `json:"kind"`
+	Count     int    `json:"count"`
+	FirstPage int    `json:"first_page"`
+	Sentences []int  `json:"-"` // indexes of sentences containing the term
+}
+
+// Sentences splits extracted text into sentences tagged with their page
+func Sentences(text string) []Sentence {
+	var sentences []Sentence
+	for _, page := range chunker.ParsePages(text) {
+		for _, s := range chunker.SplitSentences(page.Text) {
+			sentences = append(sentences, Sentence{
+				Index: len(sentences),
+				Text:  s,
+				Page:  page.Number,
+			})
+		}
+	}
+	return sentences
+}
+
+var yearRe = regexp.MustCompile(`\b1[0-9]{3}\b|\b20[0-9]{2}\b`)
+
+// connectors may appear inside a capitalized phrase ("Treaty of Tordesillas")
+var connectors = map[string]bool{
+	"of": true, "the": true, "de": true, "del": true, "la": true, "von": true, "and": true,
+}
+
+// sentenceStarters are capitalized only because they begin a sentence
+var sentenceStarters = map[string]bool{
+	"The": true, "A": true, "An": true, "In": true, "On": true, "At": true, "It": true,
+	"This": true, "That": true, "These": true, "Those": true, "He": true, "She": true,
+	"They": true, "We": true, "His": true, "Her": true, "Their": true, "After": true,
+	"Before": true, "During": true, "When": true, "While": true, "As": true, "By": true,
+	"For": true, "From": true, "With": true, "However": true, "Although": true, "Because": true,
+	"Some": true, "Many": true, "Most": true, "Each": true, "Other": true, "There": true,
+	"Its": true, "Our": true, "One": true, "To": true, "If": true, "But": true, "And": true,
+}
+
+// KeyTerms returns up to limit terms ranked by frequency, with ties broken
+// by first appearance. Capitalized phrases and years are treated as terms.
+func KeyTerms(sentences []Sentence, limit int) []Term {
+	byText := make(map[string]*Term)
+	var order []string
+
+	add := func(text, kind string, s Sentence) {
+		t, ok := byText[text]
+		if !ok {
+			t = &Term{Text: text, Kind: kind, FirstPage: s.Page}
+			byText[text] = t
+			order = append(order, text)
+		}
+		t.Count++
+		if n := len(t.Sentences); n == 0 || t.Sentences[n-1] != s.Index {
+			t.Sentences = append(t.Sentences, s.Index)
+		}
+	}
+
+	for _, s := range sentences {
+		for _, phrase := range capitalizedPhrases(s.Text) {
+			add(phrase, TermName, s)
+		}
+		for _, year := range yearRe.FindAllString(s.Text, -1) {
+			add(year, TermDate, s)
+		}
+	}
+
+	terms := make([]Term, 0, len(order))
+	for _, text := range order {
+		terms = append(terms, *byText[text])
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		return terms[i].Count > terms[j].Count
+	})
+
+	if limit > 0 && len(terms) > limit {
+		terms = terms[:limit]
+	}
+	return terms
+}
+
+// capitalizedPhrases finds runs of capitalized words, allowing lowercase
+// connectors between them and skipping sentence-initial function words
+func capitalizedPhrases(sentence string) []string {
+	words := strings.Fields(sentence)
+
+	var phrases []string
+	var run []string
+	flush := func() {
+		// Trailing connectors don't belong to the phrase
+		for len(run) > 0 && connectors[run[len(run)-1]] {
+			run = run[:len(run)-1]
+		}
+		if len(run) > 0 {
+			phrases = append(phrases, strings.Join(run, " "))
+		}
+		run = nil
+	}
+
+	for i, raw := range words {
+		word := strings.TrimFunc(raw, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if word == "" {
+			flush()
+			continue
+		}
+
+		first, _ := utf8.DecodeRuneInString(word)
+		switch {
+		case unicode.IsUpper(first) && !(i == 0 && sentenceStarters[word]):
+			run = append(run, word)
+		case len(run) > 0 && connectors[word]:
+			run = append(run, word)
+		default:
+			flush()
+		}
+
+		// Punctuation after a word ends the phrase
+		if last, _ := utf8.DecodeLastRuneInString(raw); !unicode.IsLetter(last) && !unicode.IsDigit(last) {
+			flush()
+		}
+	}
+	flush()
+
+	return phrases
+}
+
+// Blank replaces the first occurrence of term in sentence with a blank
+func Blank(sentence, term string) string {
+	return strings.Replace(sentence, term, "_____", 1)
+}
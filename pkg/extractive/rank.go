@@ -0,0 +1,88 @@
+package extractive
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var wordRe = regexp.MustCompile(`[\p{L}\p{N}']+`)
+
+// stopWords are excluded when scoring sentences
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "been": true, "but": true, "by": true, "for": true, "from": true,
+	"had": true, "has": true, "have": true, "he": true, "her": true, "his": true,
+	"in": true, "is": true, "it": true, "its": true, "of": true, "on": true,
+	"or": true, "she": true, "that": true, "the": true, "their": true, "they": true,
+	"this": true, "to": true, "was": true, "were": true, "which": true, "who": true,
+	"will": true, "with": true, "would": true,
+}
+
+// Words lowercases text and returns its non-stop-word tokens
+func Words(text string) []string {
+	var words []string
+	for _, w := range wordRe.FindAllString(strings.ToLower(text), -1) {
+		if !stopWords[w] {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// TopSentences ranks sentences by average word frequency across all of them
+// and returns the best count in document order
+func TopSentences(sentences []Sentence, count int) []Sentence {
+	if len(sentences) <= count {
+		return sentences
+	}
+
+	freq := make(map[string]int)
+	for _, s := range sentences {
+		for _, word := range Words(s.Text) {
+			freq[word]++
+		}
+	}
+
+	type scored struct {
+		index int
+		score float64
+	}
+	scores := make([]scored, len(sentences))
+	for i, s := range sentences {
+		words := Words(s.Text)
+		var total float64
+		for _, w := range words {
+			total += float64(freq[w])
+		}
+		if len(words) > 0 {
+			total /= float64(len(words))
+		}
+		scores[i] = scored{index: i, score: total}
+	}
+
+	// Stable ordering keeps ties in document order for deterministic output
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].score > scores[j].score
+	})
+
+	picked := scores[:count]
+	sort.Slice(picked, func(i, j int) bool {
+		return picked[i].index < picked[j].index
+	})
+
+	result := make([]Sentence, 0, count)
+	for _, s := range picked {
+		result = append(result, sentences[s.index])
+	}
+	return result
+}
+
+// Texts returns just the text of each sentence
+func Texts(sentences []Sentence) []string {
+	texts := make([]string, len(sentences))
+	for i, s := range sentences {
+		texts[i] = s.Text
+	}
+	return texts
+}
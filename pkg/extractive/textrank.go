@@ -0,0 +1,124 @@
+package extractive
+
+import (
+	"math"
+	"sort"
+)
+
+// TextRank parameters
+const (
+	textRankDamping    = 0.85
+	textRankIterations = 100
+	textRankTolerance  = 1e-6
+)
+
+// TextRank ranks sentences by their centrality in a graph whose edges are
+// weighted by the TF-IDF cosine similarity of each pair of sentences, and
+// returns the best count in document order
+func TextRank(sentences []Sentence, count int) []Sentence {
+	if len(sentences) <= count {
+		return sentences
+	}
+	n := len(sentences)
+
+	// Term frequencies and the number of sentences containing each term
+	termFreqs := make([]map[string]float64, n)
+	docFreq := make(map[string]int)
+	for i, s := range sentences {
+		tf := make(map[string]float64)
+		for _, w := range Words(s.Text) {
+			tf[w]++
+		}
+		for w := range tf {
+			docFreq[w]++
+		}
+		termFreqs[i] = tf
+	}
+
+	// TF-IDF vectors; terms found in every sentence carry no weight
+	vectors := make([]map[string]float64, n)
+	norms := make([]float64, n)
+	for i, tf := range termFreqs {
+		vec := make(map[string]float64, len(tf))
+		var norm float64
+		for w, f := range tf {
+			weight := f * math.Log(float64(n)/float64(docFreq[w]))
+			if weight > 0 {
+				vec[w] = weight
+				norm += weight * weight
+			}
+		}
+		vectors[i] = vec
+		norms[i] = math.Sqrt(norm)
+	}
+
+	// Similarity graph and the total edge weight leaving each sentence
+	weights := make([][]float64, n)
+	for i := range weights {
+		weights[i] = make([]float64, n)
+	}
+	outWeight := make([]float64, n)
+	for i := 0; i < n; i++ {
+		if norms[i] == 0 {
+			continue
+		}
+		for j := i + 1; j < n; j++ {
+			if norms[j] == 0 {
+				continue
+			}
+			var dot float64
+			for w, v := range vectors[i] {
+				dot += v * vectors[j][w]
+			}
+			if dot == 0 {
+				continue
+			}
+			sim := dot / (norms[i] * norms[j])
+			weights[i][j], weights[j][i] = sim, sim
+			outWeight[i] += sim
+			outWeight[j] += sim
+		}
+	}
+
+	// Weighted PageRank, iterated until the scores settle
+	scores := make([]float64, n)
+	for i := range scores {
+		scores[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+	for iter := 0; iter < textRankIterations; iter++ {
+		var delta float64
+		for i := 0; i < n; i++ {
+			var rank float64
+			for j := 0; j < n; j++ {
+				if weights[j][i] > 0 {
+					rank += weights[j][i] / outWeight[j] * scores[j]
+				}
+			}
+			next[i] = (1-textRankDamping)/float64(n) + textRankDamping*rank
+			delta += math.Abs(next[i] - scores[i])
+		}
+		scores, next = next, scores
+		if delta < textRankTolerance {
+			break
+		}
+	}
+
+	// Stable ordering keeps ties in document order for deterministic output
+	ranked := make([]int, n)
+	for i := range ranked {
+		ranked[i] = i
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return scores[ranked[a]] > scores[ranked[b]]
+	})
+
+	picked := ranked[:count]
+	sort.Ints(picked)
+
+	result := make([]Sentence, 0, count)
+	for _, i := range picked {
+		result = append(result, sentences[i])
+	}
+	return result
+}
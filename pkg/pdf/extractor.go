@@ -1,6 +1,7 @@
 package pdf
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -30,7 +31,7 @@ func (e *Extractor) GetPageCount(filePath string) (int, error) {
 
 // ExtractText extracts text from specified page range
 // Pages are 1-indexed (first page is 1)
-func (e *Extractor) ExtractText(filePath string, startPage, endPage int) (string, error) {
+func (e *Extractor) ExtractText(ctx context.Context, filePath string, startPage, endPage int) (string, error) {
 	// Validate page range
 	if startPage < 1 || endPage < startPage {
 		return "", fmt.Errorf("invalid page range: %d-%d", startPage, endPage)
@@ -55,6 +56,10 @@ func (e *Extractor) ExtractText(filePath string, startPage, endPage int) (string
 	var extractedText strings.Builder
 
 	for pageNum := startPage; pageNum <= endPage; pageNum++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
 		page := r.Page(pageNum)
 		if page.V.IsNull() {
 			continue
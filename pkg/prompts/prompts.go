@@ -0,0 +1,286 @@
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"studyforge/pkg/extractive"
+)
+
+// Subject keys with special meaning
+const (
+	GeneralSubject = "general" // used when a request names no subject
+	AutoSubject    = "auto"    // detect the subject from the source text
+)
+
+// Subject detection thresholds: the best subject needs at least
+// minKeywordHits keyword matches making up minKeywordShare of the words
+const (
+	minKeywordHits  = 3
+	minKeywordShare = 0.01
+)
+
+// levels are the academic levels a template may be specialized for
+var levels = map[string]bool{
+	"high_school":   true,
+	"undergraduate": true,
+	"graduate":      true,
+}
+
+//go:embed templates
+var embedded embed.FS
+
+// Data holds the values available to templates
+type Data struct {
+	Text       string // source excerpt, with "--- Page N ---" markers
+	Level      string // academic level: 'high_school', 'undergraduate' or 'graduate'
+	Audience   string // who the material is for, e.g. "graduate students"
+	Subject    string // subject name, e.g. "biology"; empty for general material
+	Focus      string // subject guidance for the level, filled in by Render
+	Count      int    // number of items requested
+	Mix        string // quiz question mix, e.g. "3 multiple_choice, 2 true_false"
+	MaxDepth   int    // deepest level of note bullets
+	Criteria   string // rubric criteria for essay questions
+	Question   string // question to answer from Text
+	Prompt     string // user's instruction for a custom prompt
+	PromptType string // custom prompt type, e.g. "question" or "notes"
+	Pages      string // page range of Text, e.g. "3-5"
+}
+
+// Subject describes a subject the library has guidance for
+type Subject struct {
+	Name     string   `json:"name"`     // how prompts refer to the subject
+	Keywords []string `json:"keywords"` // lowercase words that identify the subject's texts
+}
+
+// manifest is the library.json file at the root of a library
+type manifest struct {
+	Version  string             `json:"version"`
+	Subjects map[string]Subject `json:"subjects"`
+}
+
+// Library is a versioned set of prompt templates keyed by material type,
+// subject and academic level. A library directory holds:
+//
+//	library.json                      version and subjects
+//	subjects/<subject>.tmpl           subject guidance, rendered into Data.Focus
+//	<material>/<subject>.tmpl         prompt for a material type and subject
+//	<material>/<subject>.<level>.tmpl prompt specialized for an academic level
+//
+// Every material type needs a general.tmpl, which is used for any subject
+// and level without a more specific template.
+type Library struct {
+	version   string
+	subjects  map[string]Subject
+	keywords  map[string]string             // keyword to subject key
+	guidance  map[string]*template.Template // keyed by subject
+	templates map[string]*template.Template // keyed by "<material>/<subject>[.<level>]"
+}
+
+// Open loads the library in dir, or the embedded library when dir is empty
+func Open(dir string) (*Library, error) {
+	if dir == "" {
+		sub, err := fs.Sub(embedded, "templates")
+		if err != nil {
+			return nil, err
+		}
+		return Load(sub)
+	}
+	return Load(os.DirFS(dir))
+}
+
+// Load reads a library from fsys
+func Load(fsys fs.FS) (*Library, error) {
+	data, err := fs.ReadFile(fsys, "library.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt library manifest: %w", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt library manifest: %w", err)
+	}
+	if m.Version == "" {
+		return nil, fmt.Errorf("prompt library manifest has no version")
+	}
+	if _, ok := m.Subjects[GeneralSubject]; !ok {
+		return nil, fmt.Errorf("prompt library has no %q subject", GeneralSubject)
+	}
+
+	lib := &Library{
+		version:   m.Version,
+		subjects:  m.Subjects,
+		keywords:  make(map[string]string),
+		guidance:  make(map[string]*template.Template),
+		templates: make(map[string]*template.Template),
+	}
+	for key, subject := range m.Subjects {
+		for _, kw := range subject.Keywords {
+			kw = strings.ToLower(kw)
+			if other, ok := lib.keywords[kw]; ok && other != key {
+				return nil, fmt.Errorf("keyword %q belongs to subjects %q and %q", kw, other, key)
+			}
+			lib.keywords[kw] = key
+		}
+	}
+
+	dirs, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt library: %w", err)
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		if err := lib.loadDir(fsys, dir.Name()); err != nil {
+			return nil, err
+		}
+	}
+
+	return lib, nil
+}
+
+// loadDir parses the templates of one material type, or the subject
+// guidance when dir is "subjects"
+func (l *Library) loadDir(fsys fs.FS, dir string) error {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("failed to read prompt library: %w", err)
+	}
+
+	hasGeneral := false
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".tmpl")
+		if file.IsDir() || name == file.Name() {
+			continue
+		}
+
+		subject, level, _ := strings.Cut(name, ".")
+		if _, ok := l.subjects[subject]; !ok {
+			return fmt.Errorf("prompt template %s/%s: unknown subject %q", dir, file.Name(), subject)
+		}
+		if level != "" && (dir == "subjects" || !levels[level]) {
+			return fmt.Errorf("prompt template %s/%s: unknown academic level %q", dir, file.Name(), level)
+		}
+
+		text, err := fs.ReadFile(fsys, path.Join(dir, file.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		tmpl, err := template.New(dir + "/" + name).Option("missingkey=error").Parse(string(text))
+		if err != nil {
+			return fmt.Errorf("failed to parse prompt template: %w", err)
+		}
+
+		if dir == "subjects" {
+			l.guidance[subject] = tmpl
+		} else {
+			l.templates[dir+"/"+name] = tmpl
+			hasGeneral = hasGeneral || name == GeneralSubject
+		}
+	}
+
+	if dir != "subjects" && !hasGeneral {
+		return fmt.Errorf("prompt templates for %s have no %s.tmpl", dir, GeneralSubject)
+	}
+	return nil
+}
+
+// Version identifies the prompt wording; it changes whenever the library does
+func (l *Library) Version() string {
+	return l.version
+}
+
+// Subjects returns the subject keys the library knows, sorted
+func (l *Library) Subjects() []string {
+	keys := make([]string, 0, len(l.subjects))
+	for key := range l.subjects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// HasSubject reports whether subject is a known subject key
+func (l *Library) HasSubject(subject string) bool {
+	_, ok := l.subjects[subject]
+	return ok
+}
+
+// Render fills in the prompt for a material type. The most specific
+// template wins: subject and level, then subject, then the general
+// template for the level, then the general template. Unknown subjects are
+// treated as general.
+func (l *Library) Render(material, subject string, data Data) (string, error) {
+	if !l.HasSubject(subject) {
+		subject = GeneralSubject
+	}
+
+	var tmpl *template.Template
+	for _, key := range []string{
+		material + "/" + subject + "." + data.Level,
+		material + "/" + subject,
+		material + "/" + GeneralSubject + "." + data.Level,
+		material + "/" + GeneralSubject,
+	} {
+		if t, ok := l.templates[key]; ok {
+			tmpl = t
+			break
+		}
+	}
+	if tmpl == nil {
+		return "", fmt.Errorf("no prompt template for material type %q", material)
+	}
+
+	data.Subject = l.subjects[subject].Name
+	data.Focus = ""
+	if guidance, ok := l.guidance[subject]; ok {
+		focus, err := execute(guidance, data)
+		if err != nil {
+			return "", err
+		}
+		data.Focus = focus
+	}
+
+	return execute(tmpl, data)
+}
+
+// DetectSubject returns the subject whose keywords appear most often in
+// text, or GeneralSubject when no subject stands out
+func (l *Library) DetectSubject(text string) string {
+	words := extractive.Words(text)
+	hits := make(map[string]int)
+	for _, w := range words {
+		if subject, ok := l.keywords[w]; ok {
+			hits[subject]++
+		}
+	}
+
+	// Subjects are visited in sorted order so ties resolve the same way
+	best, bestHits := GeneralSubject, 0
+	for _, subject := range l.Subjects() {
+		if hits[subject] > bestHits {
+			best, bestHits = subject, hits[subject]
+		}
+	}
+	if bestHits < minKeywordHits || float64(bestHits) < minKeywordShare*float64(len(words)) {
+		return GeneralSubject
+	}
+	return best
+}
+
+// execute renders a template, trimming surrounding whitespace
+func execute(tmpl *template.Template, data Data) (string, error) {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,133 @@
+package retrieval
+
+import (
+	"math"
+	"sort"
+
+	"studyforge/pkg/extractive"
+)
+
+// BM25 parameters
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Index is an in-memory BM25 index over a set of passages
+type Index struct {
+	docs      []map[string]int // term frequencies per passage
+	lengths   []int
+	avgLength float64
+	docFreq   map[string]int // passages containing each term
+}
+
+// NewIndex builds a BM25 index over passages
+func NewIndex(passages []string) *Index {
+	idx := &Index{
+		docs:    make([]map[string]int, len(passages)),
+		lengths: make([]int, len(passages)),
+		docFreq: make(map[string]int),
+	}
+
+	total := 0
+	for i, text := range passages {
+		tf := make(map[string]int)
+		words := extractive.Words(text)
+		for _, w := range words {
+			tf[w]++
+		}
+		for w := range tf {
+			idx.docFreq[w]++
+		}
+		idx.docs[i] = tf
+		idx.lengths[i] = len(words)
+		total += len(words)
+	}
+	if len(passages) > 0 {
+		idx.avgLength = float64(total) / float64(len(passages))
+	}
+
+	return idx
+}
+
+// Scores returns the BM25 score of every passage for query, in passage order
+func (idx *Index) Scores(query string) []float64 {
+	scores := make([]float64, len(idx.docs))
+	if idx.avgLength == 0 {
+		return scores
+	}
+
+	n := float64(len(idx.docs))
+	seen := make(map[string]bool)
+	for _, term := range extractive.Words(query) {
+		if seen[term] || idx.docFreq[term] == 0 {
+			continue
+		}
+		seen[term] = true
+
+		df := float64(idx.docFreq[term])
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for i, tf := range idx.docs {
+			f := float64(tf[term])
+			if f == 0 {
+				continue
+			}
+			norm := 1 - b + b*float64(idx.lengths[i])/idx.avgLength
+			scores[i] += idf * f * (k1 + 1) / (f + k1*norm)
+		}
+	}
+	return scores
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 when their sizes
+// differ or either is zero
+func Cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Normalize scales scores into [0, 1] by dividing by the largest score
+func Normalize(scores []float64) []float64 {
+	max := 0.0
+	for _, s := range scores {
+		if s > max {
+			max = s
+		}
+	}
+
+	normalized := make([]float64, len(scores))
+	if max == 0 {
+		return normalized
+	}
+	for i, s := range scores {
+		normalized[i] = s / max
+	}
+	return normalized
+}
+
+// Top returns the indexes of the k highest positive scores, best first
+func Top(scores []float64, k int) []int {
+	var ranked []int
+	for i, s := range scores {
+		if s > 0 {
+			ranked = append(ranked, i)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return scores[ranked[i]] > scores[ranked[j]] })
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+	return ranked
+}
@@ -38,10 +38,10 @@ func (sm *SessionManager) Middleware(next http.Handler) http.Handler {
 
 		if err == nil && cookie.Value != "" {
 			// Try to load existing session
-			session, err = sm.sessionRepo.GetByID(cookie.Value)
+			session, err = sm.sessionRepo.GetByID(r.Context(), cookie.Value)
 			if err == nil {
 				// Update last accessed time
-				if err := sm.sessionRepo.UpdateLastAccessed(session.ID); err != nil {
+				if err := sm.sessionRepo.UpdateLastAccessed(r.Context(), session.ID); err != nil {
 					log.Printf("Failed to update session last accessed: %v", err)
 				}
 			}
@@ -84,7 +84,7 @@ func (sm *SessionManager) createSession(r *http.Request) (*models.Session, error
 		IsActive:     true,
 	}
 
-	if err := sm.sessionRepo.Create(session); err != nil {
+	if err := sm.sessionRepo.Create(r.Context(), session); err != nil {
 		return nil, err
 	}
 
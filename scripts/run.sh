@@ -24,11 +24,13 @@ set -a
 source .env
 set +a
 
-# Check if HuggingFace API key is set
-if [ -z "$HUGGINGFACE_API_KEY" ] || [ "$HUGGINGFACE_API_KEY" = "your_api_key_here" ]; then
-    echo "Warning: HUGGINGFACE_API_KEY is not set in .env file"
-    echo "Please add your Hugging Face API key to the .env file"
-    exit 1
+# Check if HuggingFace API key is set (only required for the huggingface provider)
+if [ "${AI_PROVIDER:-huggingface}" = "huggingface" ]; then
+    if [ -z "$HUGGINGFACE_API_KEY" ] || [ "$HUGGINGFACE_API_KEY" = "your_api_key_here" ]; then
+        echo "Warning: HUGGINGFACE_API_KEY is not set in .env file"
+        echo "Please add your Hugging Face API key to the .env file, or set AI_PROVIDER=mock to run offline"
+        exit 1
+    fi
 fi
 
 # Run the server
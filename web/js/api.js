@@ -29,7 +29,7 @@ const API = (function() {
     }
 
     // Generate study material
-    async function generateSummary(documentId, pageStart, pageEnd, academicLevel) {
+    async function generateSummary(documentId, pageStart, pageEnd, academicLevel, subject) {
         try {
             const response = await fetch(`${BASE_URL}/api/study/generate`, {
                 method: 'POST',
@@ -41,7 +41,8 @@ const API = (function() {
                     page_start: pageStart,
                     page_end: pageEnd,
                     material_type: 'summary',
-                    academic_level: academicLevel
+                    academic_level: academicLevel,
+                    subject: subject
                 })
             });
 
@@ -58,6 +59,86 @@ const API = (function() {
         }
     }
 
+    // Generate a summary over Server-Sent Events, calling onEvent(type, data)
+    // for each progress and delta event. Resolves with the final result.
+    async function generateSummaryStream(documentId, pageStart, pageEnd, academicLevel, subject, onEvent) {
+        try {
+            const response = await fetch(`${BASE_URL}/api/study/generate/stream`, {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json'
+                },
+                body: JSON.stringify({
+                    document_id: documentId,
+                    page_start: pageStart,
+                    page_end: pageEnd,
+                    material_type: 'summary',
+                    academic_level: academicLevel,
+                    subject: subject
+                })
+            });
+
+            // Invalid requests are rejected with a JSON error before the stream opens
+            if (!response.ok) {
+                const data = await response.json();
+                throw new Error(data.error?.message || 'Generation failed');
+            }
+
+            const reader = response.body.getReader();
+            const decoder = new TextDecoder();
+            let buffer = '';
+
+            while (true) {
+                const { value, done } = await reader.read();
+                if (done) {
+                    break;
+                }
+                buffer += decoder.decode(value, { stream: true });
+
+                // Events are separated by a blank line
+                let boundary;
+                while ((boundary = buffer.indexOf('\n\n')) >= 0) {
+                    const event = parseEvent(buffer.slice(0, boundary));
+                    buffer = buffer.slice(boundary + 2);
+                    if (!event) {
+                        continue;
+                    }
+
+                    if (event.type === 'done') {
+                        reader.cancel();
+                        return event.data;
+                    }
+                    if (event.type === 'error') {
+                        throw new Error(event.data.message || 'Generation failed');
+                    }
+                    onEvent(event.type, event.data);
+                }
+            }
+
+            throw new Error('Connection closed before generation finished');
+        } catch (error) {
+            console.error('Generation error:', error);
+            throw error;
+        }
+    }
+
+    // Parse one Server-Sent Event; comments and empty events return null
+    function parseEvent(block) {
+        let type = 'message';
+        const data = [];
+        for (const line of block.split('\n')) {
+            if (line.startsWith('event:')) {
+                type = line.slice(6).trim();
+            } else if (line.startsWith('data:')) {
+                data.push(line.slice(5).trim());
+            }
+        }
+        if (data.length === 0) {
+            return null;
+        }
+        return { type, data: JSON.parse(data.join('\n')) };
+    }
+
     // Get document information
     async function getDocument(documentId) {
         try {
@@ -90,6 +171,7 @@ const API = (function() {
     return {
         uploadPDF,
         generateSummary,
+        generateSummaryStream,
         getDocument,
         healthCheck
     };
@@ -15,6 +15,8 @@
     const resultsSection = document.getElementById('resultsSection');
     const generationForm = document.getElementById('generationForm');
     const loadingIndicator = document.getElementById('loadingIndicator');
+    const progressMessage = document.getElementById('progressMessage');
+    const streamPreview = document.getElementById('streamPreview');
     const errorMessage = document.getElementById('errorMessage');
     const generateAnotherBtn = document.getElementById('generateAnotherBtn');
 
@@ -130,6 +132,7 @@
         const pageStart = parseInt(document.getElementById('pageStart').value);
         const pageEnd = parseInt(document.getElementById('pageEnd').value);
         const academicLevel = document.getElementById('academicLevel').value;
+        const subject = document.getElementById('subject').value;
 
         // Validate page range
         if (pageStart < 1 || pageEnd < pageStart) {
@@ -144,14 +147,17 @@
 
         try {
             hideError();
+            resetProgress();
             loadingIndicator.style.display = 'block';
             generationForm.style.display = 'none';
 
-            const result = await API.generateSummary(
+            const result = await API.generateSummaryStream(
                 currentDocument.document_id,
                 pageStart,
                 pageEnd,
-                academicLevel
+                academicLevel,
+                subject,
+                handleStreamEvent
             );
 
             // Display results
@@ -165,11 +171,55 @@
         }
     }
 
+    // Draft text streamed so far, keyed by chunk number (0 for a single request)
+    let drafts = {};
+    // Set when a pass over the text finishes, so the next delta starts a new draft
+    let passFinished = false;
+
+    // Clear progress from a previous generation
+    function resetProgress() {
+        drafts = {};
+        passFinished = false;
+        progressMessage.textContent = 'Generating your summary... This may take a few moments.';
+        streamPreview.textContent = '';
+    }
+
+    // Render progress and output deltas as they stream in
+    function handleStreamEvent(type, event) {
+        if (type === 'progress') {
+            progressMessage.textContent = event.message;
+            if (event.stage === 'fallback') {
+                drafts = {};
+                renderDrafts();
+            } else if (event.stage === 'reduce' || (event.stage === 'chunk' && event.done === event.total)) {
+                passFinished = true;
+            }
+            return;
+        }
+
+        if (type === 'delta') {
+            if (passFinished) {
+                drafts = {};
+                passFinished = false;
+            }
+            drafts[event.part] = (drafts[event.part] || '') + event.text;
+            renderDrafts();
+        }
+    }
+
+    // Show drafts in chunk order and keep the newest text in view
+    function renderDrafts() {
+        const parts = Object.keys(drafts).map(Number).sort((a, b) => a - b);
+        streamPreview.textContent = parts.map(part => drafts[part]).join('\n\n');
+        streamPreview.scrollTop = streamPreview.scrollHeight;
+    }
+
     // Display results
     function displayResults(result, pageStart, pageEnd, academicLevel) {
         document.getElementById('resultPages').textContent = `${pageStart}-${pageEnd}`;
         document.getElementById('resultLevel').textContent = formatAcademicLevel(academicLevel);
         document.getElementById('resultTime').textContent = result.generation_time;
+        document.getElementById('resultCached').hidden = !result.cached;
         document.getElementById('summaryContent').textContent = result.summary;
 
         loadingIndicator.style.display = 'none';